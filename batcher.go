@@ -0,0 +1,115 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 6:30 下午
+ * @Desc: a batcher merging concurrent gets
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dobyte/cache/internal/conv"
+)
+
+// defaultBatchSize The default number of keys flushing a batch before its window ends.
+const defaultBatchSize = 100
+
+type (
+	getBatcher struct {
+		window  time.Duration
+		size    int
+		fetch   func(ctx context.Context, keys ...string) (map[string]Result, error)
+		mu      sync.Mutex
+		pending *getBatch
+	}
+
+	getBatch struct {
+		ctx   context.Context
+		keys  []string
+		index map[string]struct{}
+		timer *time.Timer
+		done  chan struct{}
+		ret   map[string]Result
+		err   error
+	}
+)
+
+// newGetBatcher Create a batcher merging the gets arriving within the window, or until the size of keys are queued,
+// into a single fetch of multiple keys.
+func newGetBatcher(window time.Duration, size int, fetch func(ctx context.Context, keys ...string) (map[string]Result, error)) *getBatcher {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	return &getBatcher{
+		window: window,
+		size:   size,
+		fetch:  fetch,
+	}
+}
+
+// get Queue a key into the pending batch and wait for its result, the caller may abandon the wait through its context
+// without affecting the others. The batch is fetched with the context of its first caller, detached from its cancellation.
+func (b *getBatcher) get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	b.mu.Lock()
+	batch := b.pending
+	if batch == nil {
+		batch = &getBatch{
+			ctx:   context.WithoutCancel(ctx),
+			index: make(map[string]struct{}),
+			done:  make(chan struct{}),
+		}
+		batch.timer = time.AfterFunc(b.window, func() { b.flush(batch) })
+		b.pending = batch
+	}
+
+	if _, ok := batch.index[key]; !ok {
+		batch.index[key] = struct{}{}
+		batch.keys = append(batch.keys, key)
+	}
+	full := len(batch.keys) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		b.flush(batch)
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return NewResult("", ctx.Err())
+	}
+
+	if batch.err != nil {
+		return NewResult("", batch.err)
+	}
+
+	if rst, ok := batch.ret[key]; ok && rst.Err() != Nil {
+		return rst
+	}
+
+	if len(defaultValue) > 0 {
+		return NewResult(conv.String(defaultValue[0]))
+	}
+
+	return NewResult("", Nil)
+}
+
+// flush Fetch the keys of a batch unless it has been flushed already.
+func (b *getBatcher) flush(batch *getBatch) {
+	b.mu.Lock()
+	if b.pending != batch {
+		b.mu.Unlock()
+		return
+	}
+	b.pending = nil
+	b.mu.Unlock()
+
+	batch.timer.Stop()
+	batch.ret, batch.err = b.fetch(batch.ctx, batch.keys...)
+	close(batch.done)
+}
@@ -0,0 +1,108 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 6:50 下午
+ * @Desc: get batcher test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+func TestRedisStore_Batch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		m     = miniredis.RunT(t)
+		store = cache.NewRedisStore(&cache.RedisOptions{
+			Addrs:       []string{m.Addr()},
+			Prefix:      "cache",
+			BatchWindow: 20 * time.Millisecond,
+			BatchSize:   20,
+		})
+		wg sync.WaitGroup
+	)
+
+	for i := 0; i < 5; i++ {
+		if err := store.Set(ctx, fmt.Sprintf("key%d", i), i, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := m.CommandCount()
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", i%5)
+			if val, err := store.Get(ctx, key).Int(); err != nil || val != i%5 {
+				t.Errorf("batch: unexpected value of %s, %v, %v", key, val, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := m.CommandCount() - count; n != 1 {
+		t.Fatalf("batch: expected the gets to be merged into a single command, sent %d", n)
+	}
+
+	if val := store.Get(ctx, "missing", "fuxiao").Val(); val != "fuxiao" {
+		t.Fatalf("batch: expected the default value, got %q", val)
+	}
+
+	if err := store.Get(ctx, "missing").Err(); err != cache.Nil {
+		t.Fatalf("batch: expected cache.Nil, got %v", err)
+	}
+}
+
+func TestRedisStore_BatchSize(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = cache.NewRedisStore(&cache.RedisOptions{
+			Addrs:       []string{miniredis.RunT(t).Addr()},
+			BatchWindow: time.Hour,
+			BatchSize:   2,
+		})
+		wg sync.WaitGroup
+	)
+
+	_ = store.Set(ctx, "name", "fuxiao", time.Minute)
+
+	for _, key := range []string{"name", "missing"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_ = store.Get(ctx, key)
+		}(key)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch: expected the full batch to be flushed before its window ends")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := store.Get(canceled, "name").Err(); err != context.Canceled {
+		t.Fatalf("batch: expected the canceled get to return, got %v", err)
+	}
+}
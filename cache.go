@@ -10,6 +10,8 @@ package cache
 import (
 	"context"
 	"time"
+
+	"github.com/dobyte/cache/internal/sync"
 )
 
 type Cache interface {
@@ -18,56 +20,95 @@ type Cache interface {
 	// HasMany Determine if multiple item exists in the cache.
 	HasMany(ctx context.Context, keys ...string) (map[string]bool, error)
 	// Get Retrieve an item from the cache by key.
-	Get(key string, defaultValue ...interface{}) Result
+	Get(ctx context.Context, key string, defaultValue ...interface{}) Result
 	// GetMany Retrieve multiple items from the cache by key.
 	GetMany(ctx context.Context, keys ...string) (map[string]Result, error)
 	// GetSet Retrieve or set an item from the cache by key.
-	GetSet(key string, fn func() (interface{}, time.Duration, error)) Result
+	GetSet(ctx context.Context, key string, fn func() (interface{}, time.Duration, error), opt ...*GetSetOptions) Result
+	// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn
+	// and stored for the returned expiration, and the keys the fn leaves out are cached as nil.
+	GetManySet(ctx context.Context, keys []string, fn func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error)) (map[string]Result, error)
+	// GetWithVersion Retrieve an item from the cache by key along with its opaque version.
+	GetWithVersion(ctx context.Context, key string) (Result, Version)
+	// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+	// report whether it was stored.
+	CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error)
+	// Update Atomically replace an item with the value computed from its current value,
+	// retrying on conflict up to 16 times before returning cache.ErrCASConflict.
+	Update(ctx context.Context, key string, expire time.Duration, fn UpdateFunc) error
+	// Flexible Retrieve an item from the cache by key, the item older than the fresh is served until the stale
+	// while a single process refreshes it in the background, and the missing item is loaded like GetSet.
+	Flexible(ctx context.Context, key string, fresh, stale time.Duration, fn FlexibleFunc) Result
 	// Set Store an item in the cache.
-	Set(key string, value interface{}, expire time.Duration) error
+	Set(ctx context.Context, key string, value interface{}, expire time.Duration) error
 	// SetMany Store multiple items in the cache for a given number of expire.
-	SetMany(values map[string]interface{}, expire time.Duration) error
+	SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error
 	// Forever Store an item in the cache indefinitely.
-	Forever(key string, value interface{}) error
+	Forever(ctx context.Context, key string, value interface{}) error
 	// ForeverMany Store multiple items in the cache indefinitely.
-	ForeverMany(values map[string]interface{}) error
+	ForeverMany(ctx context.Context, values map[string]interface{}) error
 	// Add Store an item in the cache if the key does not exist.
-	Add(key string, value interface{}, expire time.Duration) (bool, error)
+	Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error)
 	// Increment Increment the value of an item in the cache.
-	Increment(key string, value int64) (int64, error)
+	Increment(ctx context.Context, key string, value int64) (int64, error)
 	// IncrementMany Increment the value of multiple items in the cache.
-	IncrementMany(values map[string]int64) (map[string]int64, error)
+	IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error)
 	// Decrement Decrement the value of an item in the cache.
-	Decrement(key string, value int64) (int64, error)
+	Decrement(ctx context.Context, key string, value int64) (int64, error)
 	// DecrementMany Decrement the value of multiple items in the cache.
-	DecrementMany(values map[string]int64) (map[string]int64, error)
+	DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error)
 	// Forget Remove an item from the cache.
-	Forget(key string) error
+	Forget(ctx context.Context, key string) error
 	// ForgetMany Remove multiple items from the cache.
-	ForgetMany(keys ...string) (int64, error)
+	ForgetMany(ctx context.Context, keys ...string) (int64, error)
 	// Expire Set expiration time for a key.
-	Expire(key string, expire time.Duration) (bool, error)
+	Expire(ctx context.Context, key string, expire time.Duration) (bool, error)
 	// ExpireMany Set expiration time for multiple key.
-	ExpireMany(values map[string]time.Duration) (map[string]bool, error)
+	ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error)
 	// Flush Remove all items from the cache.
-	Flush() error
+	Flush(ctx context.Context) error
 	// Lock Get a lock instance.
 	Lock(name string, time time.Duration) Lock
+	// RestoreLock Restore a lock instance using the owner token.
+	RestoreLock(name string, owner string) Lock
+	// RWLock Get a read-write lock instance.
+	RWLock(name string, time time.Duration) RWLock
+	// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+	Semaphore(name string, permits int, time time.Duration) Semaphore
+	// FencingToken Get the latest fencing token issued for a lock, zero if never. The tokens only grow
+	// as long as the store keeps the counter, which is never evicted by the memory store. On redis it's a key
+	// without expiration, kept under a noeviction or volatile-* maxmemory policy only, and memcached may evict
+	// it at any time, in which case the tokens restart from 1.
+	FencingToken(ctx context.Context, name string) (int64, error)
+	// CheckFencingToken Determine if a fencing token is still the latest one issued for a lock.
+	CheckFencingToken(ctx context.Context, name string, token int64) (bool, error)
+	// Funnel Get a funnel instance limiting the jobs running at the same moment.
+	Funnel(name string) *Funnel
+	// Pipeline Get a pipeline queuing mixed operations to run them all at once.
+	Pipeline() *Pipeline
 	// PrefixKey Add prefix to the front of key.
 	PrefixKey(key string) string
+	// TaggedKey Add prefix and the namespace of the tags if any to the front of key.
+	TaggedKey(ctx context.Context, key string) (string, error)
 	// GetClient Get a client instance.
 	GetClient() interface{}
+	// Close Release the resources held by the underlying store.
+	Close() error
+	// Tags Begin executing a new tags operation.
+	Tags(names ...string) Cache
 }
 
 const (
 	RedisDriver     = "redis"
 	MemcachedDriver = "memcached"
+	MemoryDriver    = "memory"
 )
 
 type (
 	Stores struct {
 		Redis     *RedisOptions
 		Memcached *MemcachedOptions
+		Memory    *MemoryOptions
 	}
 
 	Options struct {
@@ -75,11 +116,19 @@ type (
 		Prefix           string
 		DefaultNilValue  string
 		DefaultNilExpire int64
-		Stores           Stores
+		Codec            Codec
+		// Compressor The compressor compressing the values written by the redis and memcached stores.
+		Compressor Compressor
+		// CompressThreshold The values shorter than the threshold in bytes are stored uncompressed, default 1024.
+		CompressThreshold int
+		// Keyring The keyring sealing the values, the values are stored in plain if it's nil.
+		Keyring *Keyring
+		Stores  Stores
 	}
 
 	cache struct {
 		store Store
+		group *sync.SharedCallGroup
 	}
 )
 
@@ -91,23 +140,38 @@ func NewCache(opt *Options) Cache {
 		store = newRedisStore(opt)
 	case MemcachedDriver:
 		store = newMemcachedStore(opt)
+	case MemoryDriver:
+		store = newMemoryStore(opt)
 	}
 
+	return NewCacheWithStore(store)
+}
+
+// NewCacheWithStore Create a cache instance with a given store, such as a tiered store.
+func NewCacheWithStore(store Store) Cache {
 	return &cache{
 		store: store,
+		group: sync.NewSharedCallGroup(""),
 	}
 }
 
 // Create a redis store instance.
 func newRedisStore(opt *Options) Store {
 	option := &RedisOptions{
-		Addrs:            opt.Stores.Redis.Addrs,
-		Username:         opt.Stores.Redis.Username,
-		Password:         opt.Stores.Redis.Password,
-		DB:               opt.Stores.Redis.DB,
-		Prefix:           opt.Prefix,
-		DefaultNilValue:  opt.DefaultNilValue,
-		DefaultNilExpire: opt.DefaultNilExpire,
+		Addrs:             opt.Stores.Redis.Addrs,
+		Username:          opt.Stores.Redis.Username,
+		Password:          opt.Stores.Redis.Password,
+		DB:                opt.Stores.Redis.DB,
+		Prefix:            opt.Prefix,
+		DefaultNilValue:   opt.DefaultNilValue,
+		DefaultNilExpire:  opt.DefaultNilExpire,
+		Codec:             opt.Codec,
+		Compressor:        opt.Compressor,
+		CompressThreshold: opt.CompressThreshold,
+		Keyring:           opt.Keyring,
+		BatchWindow:       opt.Stores.Redis.BatchWindow,
+		BatchSize:         opt.Stores.Redis.BatchSize,
+		TxMaxRetries:      opt.Stores.Redis.TxMaxRetries,
 	}
 
 	if opt.Stores.Redis.Prefix != "" {
@@ -122,16 +186,39 @@ func newRedisStore(opt *Options) Store {
 		option.DefaultNilExpire = opt.Stores.Redis.DefaultNilExpire
 	}
 
+	if opt.Stores.Redis.Codec != nil {
+		option.Codec = opt.Stores.Redis.Codec
+	}
+
+	if opt.Stores.Redis.Keyring != nil {
+		option.Keyring = opt.Stores.Redis.Keyring
+	}
+
+	if opt.Stores.Redis.Compressor != nil {
+		option.Compressor = opt.Stores.Redis.Compressor
+	}
+
+	if opt.Stores.Redis.CompressThreshold != 0 {
+		option.CompressThreshold = opt.Stores.Redis.CompressThreshold
+	}
+
 	return NewRedisStore(option)
 }
 
 // Create a memcached store instance.
 func newMemcachedStore(opt *Options) Store {
 	option := &MemcachedOptions{
-		Addrs:            opt.Stores.Memcached.Addrs,
-		Prefix:           opt.Prefix,
-		DefaultNilValue:  opt.DefaultNilValue,
-		DefaultNilExpire: opt.DefaultNilExpire,
+		Addrs:             opt.Stores.Memcached.Addrs,
+		Prefix:            opt.Prefix,
+		DefaultNilValue:   opt.DefaultNilValue,
+		DefaultNilExpire:  opt.DefaultNilExpire,
+		Codec:             opt.Codec,
+		Compressor:        opt.Compressor,
+		CompressThreshold: opt.CompressThreshold,
+		Keyring:           opt.Keyring,
+		BatchWindow:       opt.Stores.Memcached.BatchWindow,
+		BatchSize:         opt.Stores.Memcached.BatchSize,
+		PipelineWorkers:   opt.Stores.Memcached.PipelineWorkers,
 	}
 
 	if opt.Stores.Memcached.Prefix != "" {
@@ -146,16 +233,77 @@ func newMemcachedStore(opt *Options) Store {
 		option.DefaultNilExpire = opt.Stores.Memcached.DefaultNilExpire
 	}
 
+	if opt.Stores.Memcached.Codec != nil {
+		option.Codec = opt.Stores.Memcached.Codec
+	}
+
+	if opt.Stores.Memcached.Keyring != nil {
+		option.Keyring = opt.Stores.Memcached.Keyring
+	}
+
+	if opt.Stores.Memcached.Compressor != nil {
+		option.Compressor = opt.Stores.Memcached.Compressor
+	}
+
+	if opt.Stores.Memcached.CompressThreshold != 0 {
+		option.CompressThreshold = opt.Stores.Memcached.CompressThreshold
+	}
+
 	return NewMemcachedStore(option)
 }
 
-// Has Determine if an item exists in the cache.
+// Create a memory store instance.
+func newMemoryStore(opt *Options) Store {
+	option := &MemoryOptions{
+		Prefix:           opt.Prefix,
+		DefaultNilValue:  opt.DefaultNilValue,
+		DefaultNilExpire: opt.DefaultNilExpire,
+		Codec:            opt.Codec,
+		Keyring:          opt.Keyring,
+	}
+
+	if opt.Stores.Memory == nil {
+		return NewMemoryStore(option)
+	}
+
+	option.MaxEntries = opt.Stores.Memory.MaxEntries
+	option.MaxBytes = opt.Stores.Memory.MaxBytes
+	option.CleanupInterval = opt.Stores.Memory.CleanupInterval
+
+	if opt.Stores.Memory.Prefix != "" {
+		option.Prefix = opt.Stores.Memory.Prefix
+	}
+
+	if opt.Stores.Memory.DefaultNilValue != "" {
+		option.DefaultNilValue = opt.Stores.Memory.DefaultNilValue
+	}
+
+	if opt.Stores.Memory.DefaultNilExpire != 0 {
+		option.DefaultNilExpire = opt.Stores.Memory.DefaultNilExpire
+	}
+
+	if opt.Stores.Memory.Codec != nil {
+		option.Codec = opt.Stores.Memory.Codec
+	}
+
+	if opt.Stores.Memory.Keyring != nil {
+		option.Keyring = opt.Stores.Memory.Keyring
+	}
+
+	return NewMemoryStore(option)
+}
+
+// Has Determine if an item exists in the cache, the concurrent lookups of a key share a single round trip
+// which isn't canceled along with the context of any of them.
 func (c *cache) Has(ctx context.Context, key string) (bool, error) {
-	val, err := storeSharedCallGroup.Call(key, func() (interface{}, error) {
-		return c.store.Has(ctx, key)
+	val, err := c.group.Call(ctx, "has:"+key, func() (interface{}, error) {
+		return c.store.Has(context.WithoutCancel(ctx), key)
 	})
+	if err != nil {
+		return false, err
+	}
 
-	return val.(bool), err
+	return val.(bool), nil
 }
 
 // HasMany Determine if multiple item exists in the cache.
@@ -163,11 +311,15 @@ func (c *cache) HasMany(ctx context.Context, keys ...string) (map[string]bool, e
 	return c.store.HasMany(ctx, keys...)
 }
 
-// Get Retrieve an item from the cache by key.
-func (c *cache) Get(key string, defaultValue ...interface{}) Result {
-	rst, _ := storeSharedCallGroup.Call(key, func() (interface{}, error) {
-		return c.store.Get(context.Background(), key, defaultValue...), nil
+// Get Retrieve an item from the cache by key, the concurrent lookups of a key share a single round trip
+// which isn't canceled along with the context of any of them.
+func (c *cache) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	rst, err := c.group.Call(ctx, "get:"+key, func() (interface{}, error) {
+		return c.store.Get(context.WithoutCancel(ctx), key, defaultValue...), nil
 	})
+	if err != nil {
+		return NewResult("", err)
+	}
 
 	return rst.(Result)
 }
@@ -178,78 +330,107 @@ func (c *cache) GetMany(ctx context.Context, keys ...string) (map[string]Result,
 }
 
 // GetSet Retrieve or set an item from the cache by key.
-func (c *cache) GetSet(key string, fn func() (interface{}, time.Duration, error)) Result {
-	return c.store.GetSet(key, fn)
+func (c *cache) GetSet(ctx context.Context, key string, fn func() (interface{}, time.Duration, error), opt ...*GetSetOptions) Result {
+	return getSet(ctx, c.store, c.group, key, fn, opt...)
+}
+
+// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn
+// and stored for the returned expiration, and the keys the fn leaves out are cached as nil.
+func (c *cache) GetManySet(ctx context.Context, keys []string, fn func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error)) (map[string]Result, error) {
+	return c.store.GetManySet(ctx, keys, fn)
+}
+
+// Flexible Retrieve an item from the cache by key, the item older than the fresh is served until the stale
+// while a single process refreshes it in the background, and the missing item is loaded like GetSet.
+func (c *cache) Flexible(ctx context.Context, key string, fresh, stale time.Duration, fn FlexibleFunc) Result {
+	return flexible(ctx, c.store, c.group, key, fresh, stale, fn)
+}
+
+// GetWithVersion Retrieve an item from the cache by key along with its opaque version.
+func (c *cache) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	return c.store.GetWithVersion(ctx, key)
+}
+
+// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+// report whether it was stored.
+func (c *cache) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	return c.store.CompareAndSwap(ctx, key, version, value, expire)
+}
+
+// Update Atomically replace an item with the value computed from its current value,
+// retrying on conflict up to 16 times before returning cache.ErrCASConflict.
+func (c *cache) Update(ctx context.Context, key string, expire time.Duration, fn UpdateFunc) error {
+	return updateItem(ctx, c.store, key, expire, fn)
 }
 
 // Set Store an item in the cache.
-func (c *cache) Set(key string, value interface{}, expire time.Duration) error {
-	return c.store.Set(key, value, expire)
+func (c *cache) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	return c.store.Set(ctx, key, value, expire)
 }
 
 // SetMany Store multiple items in the cache for a given number of expire.
-func (c *cache) SetMany(values map[string]interface{}, expire time.Duration) error {
-	return c.store.SetMany(values, expire)
+func (c *cache) SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error {
+	return c.store.SetMany(ctx, values, expire)
 }
 
 // Forever Store an item in the cache indefinitely.
-func (c *cache) Forever(key string, value interface{}) error {
-	return c.store.Forever(key, value)
+func (c *cache) Forever(ctx context.Context, key string, value interface{}) error {
+	return c.store.Forever(ctx, key, value)
 }
 
 // ForeverMany Store multiple items in the cache indefinitely.
-func (c *cache) ForeverMany(values map[string]interface{}) error {
-	return c.store.ForeverMany(values)
+func (c *cache) ForeverMany(ctx context.Context, values map[string]interface{}) error {
+	return c.store.ForeverMany(ctx, values)
 }
 
 // Add Store an item in the cache if the key does not exist.
-func (c *cache) Add(key string, value interface{}, expire time.Duration) (bool, error) {
-	return c.store.Add(key, value, expire)
+func (c *cache) Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	return c.store.Add(ctx, key, value, expire)
 }
 
 // Increment Increment the value of an item in the cache.
-func (c *cache) Increment(key string, value int64) (int64, error) {
-	return c.store.Increment(key, value)
+func (c *cache) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	return c.store.Increment(ctx, key, value)
 }
 
 // IncrementMany Increment the value of multiple items in the cache.
-func (c *cache) IncrementMany(values map[string]int64) (map[string]int64, error) {
-	return c.store.IncrementMany(values)
+func (c *cache) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	return c.store.IncrementMany(ctx, values)
 }
 
 // Decrement Decrement the value of an item in the cache.
-func (c *cache) Decrement(key string, value int64) (int64, error) {
-	return c.store.Decrement(key, value)
+func (c *cache) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	return c.store.Decrement(ctx, key, value)
 }
 
 // DecrementMany Decrement the value of multiple items in the cache.
-func (c *cache) DecrementMany(values map[string]int64) (map[string]int64, error) {
-	return c.store.DecrementMany(values)
+func (c *cache) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	return c.store.DecrementMany(ctx, values)
 }
 
 // Forget Remove an item from the cache.
-func (c *cache) Forget(key string) error {
-	return c.store.Forget(key)
+func (c *cache) Forget(ctx context.Context, key string) error {
+	return c.store.Forget(ctx, key)
 }
 
 // ForgetMany Remove multiple items from the cache.
-func (c *cache) ForgetMany(keys ...string) (int64, error) {
-	return c.store.ForgetMany(keys...)
+func (c *cache) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
+	return c.store.ForgetMany(ctx, keys...)
 }
 
 // Expire Set expiration time for a key.
-func (c *cache) Expire(key string, expire time.Duration) (bool, error) {
-	return c.store.Expire(key, expire)
+func (c *cache) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	return c.store.Expire(ctx, key, expire)
 }
 
 // ExpireMany Set expiration time for multiple key.
-func (c *cache) ExpireMany(values map[string]time.Duration) (map[string]bool, error) {
-	return c.store.ExpireMany(values)
+func (c *cache) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
+	return c.store.ExpireMany(ctx, values)
 }
 
 // Flush Remove all items from the cache.
-func (c *cache) Flush() error {
-	return c.store.Flush()
+func (c *cache) Flush(ctx context.Context) error {
+	return c.store.Flush(ctx)
 }
 
 // Lock Get a lock instance.
@@ -257,12 +438,62 @@ func (c *cache) Lock(name string, time time.Duration) Lock {
 	return c.store.Lock(name, time)
 }
 
+// RestoreLock Restore a lock instance using the owner token.
+func (c *cache) RestoreLock(name string, owner string) Lock {
+	return c.store.RestoreLock(name, owner)
+}
+
+// RWLock Get a read-write lock instance.
+func (c *cache) RWLock(name string, time time.Duration) RWLock {
+	return c.store.RWLock(name, time)
+}
+
+// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+func (c *cache) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return c.store.Semaphore(name, permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock, zero if never.
+func (c *cache) FencingToken(ctx context.Context, name string) (int64, error) {
+	return c.store.FencingToken(ctx, name)
+}
+
+// CheckFencingToken Determine if a fencing token is still the latest one issued for a lock.
+func (c *cache) CheckFencingToken(ctx context.Context, name string, token int64) (bool, error) {
+	return checkFencingToken(ctx, c.store, name, token)
+}
+
+// Funnel Get a funnel instance limiting the jobs running at the same moment.
+func (c *cache) Funnel(name string) *Funnel {
+	return newFunnel(c, name)
+}
+
+// Pipeline Get a pipeline queuing mixed operations to run them all at once.
+func (c *cache) Pipeline() *Pipeline {
+	return c.store.Pipeline()
+}
+
 // PrefixKey Add prefix to the front of key.
 func (c *cache) PrefixKey(key string) string {
 	return c.store.PrefixKey(key)
 }
 
+// TaggedKey Add prefix to the front of key, the cache has no tags.
+func (c *cache) TaggedKey(ctx context.Context, key string) (string, error) {
+	return c.store.PrefixKey(key), nil
+}
+
 // GetClient Get a client instance.
 func (c *cache) GetClient() interface{} {
 	return c.store.GetClient()
 }
+
+// Close Release the resources held by the underlying store.
+func (c *cache) Close() error {
+	return c.store.Close()
+}
+
+// Tags Begin executing a new tags operation.
+func (c *cache) Tags(names ...string) Cache {
+	return newTaggedCache(c.store, names)
+}
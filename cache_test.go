@@ -9,30 +9,35 @@ package cache_test
 
 import (
 	"context"
+	"errors"
+	"net"
 	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
 
 	"github.com/dobyte/cache"
 )
 
-func newRedisCache() cache.Cache {
+func newRedisCache(t *testing.T) cache.Cache {
 	return cache.NewCache(&cache.Options{
 		Driver: cache.RedisDriver,
 		Prefix: "cache",
 		Stores: cache.Stores{
 			Redis: &cache.RedisOptions{
-				Addrs: []string{"127.0.0.1:6379"},
+				Addrs: []string{miniredis.RunT(t).Addr()},
 			},
 		},
 	})
 }
 
-func newMemcachedCache() cache.Cache {
+func newMemcachedCache(addr string) cache.Cache {
 	return cache.NewCache(&cache.Options{
 		Driver: cache.MemcachedDriver,
 		Prefix: "cache",
 		Stores: cache.Stores{
 			Memcached: &cache.MemcachedOptions{
-				Addrs: []string{":11211"},
+				Addrs: []string{addr},
 			},
 		},
 	})
@@ -95,7 +100,7 @@ func newMemcachedCache() cache.Cache {
 // }
 //
 func TestCache_GetMany(t *testing.T) {
-	redis := newRedisCache()
+	redis := newRedisCache(t)
 
 	ret, err := redis.GetMany(context.Background(), "a", "b")
 	if err != nil {
@@ -108,8 +113,54 @@ func TestCache_GetMany(t *testing.T) {
 	}
 }
 
+func TestCache_GetManySet(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory": cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":  newRedisCache(t),
+		"tagged": newRedisCache(t).Tags("people"),
+		"tiered": cache.NewCacheWithStore(cache.NewTieredStore(
+			cache.NewMemoryStore(&cache.MemoryOptions{Prefix: "cache"}),
+			cache.NewRedisStore(&cache.RedisOptions{Addrs: []string{miniredis.RunT(t).Addr()}, Prefix: "cache"}),
+			nil,
+		)),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				loads [][]string
+			)
+
+			fn := func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error) {
+				loads = append(loads, missing)
+				return map[string]interface{}{"b": "lucy"}, time.Minute, nil
+			}
+
+			if err := c.Set(ctx, "a", "fuxiao", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				ret, err := c.GetManySet(ctx, []string{"a", "b", "c"}, fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if ret["a"].Val() != "fuxiao" || ret["b"].Val() != "lucy" || ret["c"].Err() != cache.Nil {
+					t.Fatalf("getmanyset: unexpected results %v, %v, %v", ret["a"].Val(), ret["b"].Val(), ret["c"].Err())
+				}
+			}
+
+			if len(loads) != 1 || len(loads[0]) != 2 || loads[0][0] != "b" || loads[0][1] != "c" {
+				t.Fatalf("getmanyset: expected a single load of the missing keys, got %v", loads)
+			}
+		})
+	}
+}
+
 func TestCache_HasMany(t *testing.T) {
-	redis := newRedisCache()
+	redis := newRedisCache(t)
 
 	ret, err := redis.HasMany(context.Background(), "a", "b")
 	if err != nil {
@@ -122,6 +173,48 @@ func TestCache_HasMany(t *testing.T) {
 	}
 }
 
+func TestCache_Context(t *testing.T) {
+	redis := newRedisCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := redis.Set(ctx, "name", "fuxiao", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Fatalf("redis: expected the canceled context to abort the call, got %v", err)
+	}
+
+	// a memcached server that accepts the connections but never replies
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	memcached := newMemcachedCache(ln.Addr().String())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err = memcached.Get(ctx, "name").Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("mc: expected the deadline to bound the call, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Fatalf("mc: expected the call to return at the deadline, took %v", elapsed)
+	}
+}
+
 //
 // func TestCache_GetSet(t *testing.T) {
 // 	var (
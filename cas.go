@@ -0,0 +1,80 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 8:20 下午
+ * @Desc: optimistic concurrency define
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// revisionMarker The first byte of a redis value stamped with a random revision by GetWithVersion, followed by
+// the revision and the stored value. Any plain write overwrites the stamp, so a value written back is told apart.
+const revisionMarker = '\x05'
+
+// revisionSize The length of the revision stamped in front of a redis value.
+const revisionSize = 32
+
+// maxUpdateRetries The maximum number of the read-modify-write rounds of Update before giving up on conflicts.
+const maxUpdateRetries = 16
+
+// Version An opaque version of an item returned by GetWithVersion, the zero version stands for a missing item.
+type Version struct {
+	exists bool
+	// revision The revision of the item, compared by the memory store.
+	revision uint64
+	// raw The value stored in the cache along with its revision stamp, compared by the redis store.
+	raw string
+	// item The item carrying the cas id, compared by the memcached store.
+	item *memcache.Item
+}
+
+// Exists Determine if the item existed when the version was read.
+func (v Version) Exists() bool {
+	return v.exists
+}
+
+// UpdateFunc Compute the new value of an item from its current value, the result carries cache.Nil if it's missing.
+type UpdateFunc func(old Result) (interface{}, error)
+
+// updateItem Read an item along with its version, compute the new value and store it if the item
+// hasn't changed since, the rounds are retried on conflict up to maxUpdateRetries times.
+func updateItem(ctx context.Context, store Store, key string, ttl time.Duration, fn UpdateFunc) error {
+	for i := 0; i < maxUpdateRetries; i++ {
+		rst, version := store.GetWithVersion(ctx, key)
+		if err := rst.Err(); err != nil && err != Nil {
+			return err
+		}
+
+		value, err := fn(rst)
+		if err != nil {
+			return err
+		}
+
+		ok, err := store.CompareAndSwap(ctx, key, version, value, ttl)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return ErrCASConflict
+}
+
+// unstampValue Remove the revision stamped by GetWithVersion from the front of a stored value.
+func unstampValue(val string) string {
+	if len(val) > revisionSize && val[0] == revisionMarker {
+		return val[1+revisionSize:]
+	}
+
+	return val
+}
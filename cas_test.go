@@ -0,0 +1,190 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 8:40 下午
+ * @Desc: optimistic concurrency test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestCache_CompareAndSwap(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory":    cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":     newRedisCache(t),
+		"encrypted": cache.NewCacheWithStore(newMemoryStore(&cache.MemoryOptions{Keyring: newKeyring(t, "a", "a")})),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			rst, version := c.GetWithVersion(ctx, "name")
+			if rst.Err() != cache.Nil || version.Exists() {
+				t.Fatalf("cas: expected a missing item, got %v", rst.Err())
+			}
+
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "fuxiao", time.Minute); err != nil || !ok {
+				t.Fatalf("cas: expected the missing item to be added, %v", err)
+			}
+
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "lucy", time.Minute); err != nil || ok {
+				t.Fatalf("cas: expected the existing item not to be added again, %v", err)
+			}
+
+			rst, version = c.GetWithVersion(ctx, "name")
+			if rst.Val() != "fuxiao" || !version.Exists() {
+				t.Fatalf("cas: unexpected value %q", rst.Val())
+			}
+
+			_ = c.Set(ctx, "name", "lucy", time.Minute)
+
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "jack", time.Minute); err != nil || ok {
+				t.Fatalf("cas: expected the changed item not to be swapped, %v", err)
+			}
+
+			_, version = c.GetWithVersion(ctx, "name")
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "jack", time.Minute); err != nil || !ok {
+				t.Fatalf("cas: expected the unchanged item to be swapped, %v", err)
+			}
+
+			if val := c.Get(ctx, "name").Val(); val != "jack" {
+				t.Fatalf("cas: unexpected value %q", val)
+			}
+		})
+	}
+}
+
+func TestCache_CompareAndSwapABA(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory":    cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":     newRedisCache(t),
+		"memcached": cache.NewCacheWithStore(newMemcachedStore(t, nil)),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_ = c.Set(ctx, "name", "fuxiao", time.Minute)
+			_, version := c.GetWithVersion(ctx, "name")
+
+			// the item is written back to the value of the version, it's still changed since the version was read
+			_ = c.Set(ctx, "name", "lucy", time.Minute)
+			_ = c.Set(ctx, "name", "fuxiao", time.Minute)
+
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "jack", time.Minute); err != nil || ok {
+				t.Fatalf("cas: expected the item written again not to be swapped, %v", err)
+			}
+
+			_, version = c.GetWithVersion(ctx, "name")
+			_, stale := c.GetWithVersion(ctx, "name")
+
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "lucy", time.Minute); err != nil || !ok {
+				t.Fatalf("cas: expected the unchanged item to be swapped, %v", err)
+			}
+
+			_, version = c.GetWithVersion(ctx, "name")
+			if ok, err := c.CompareAndSwap(ctx, "name", version, "fuxiao", time.Minute); err != nil || !ok {
+				t.Fatalf("cas: expected the unchanged item to be swapped, %v", err)
+			}
+
+			if ok, err := c.CompareAndSwap(ctx, "name", stale, "jack", time.Minute); err != nil || ok {
+				t.Fatalf("cas: expected the item swapped back not to be swapped with a stale version, %v", err)
+			}
+
+			_ = c.Forever(ctx, "counter", 1)
+			_, version = c.GetWithVersion(ctx, "counter")
+			_, _ = c.Increment(ctx, "counter", 1)
+			_, _ = c.Decrement(ctx, "counter", 1)
+
+			// a redis counter carries no revision stamp, it's compared by value
+			if ok, err := c.CompareAndSwap(ctx, "counter", version, 5, 0); err != nil || ok != (name == "redis") {
+				t.Fatalf("cas: unexpected swap of the counter incremented and decremented %v, %v", ok, err)
+			}
+
+			if _, err := c.Increment(ctx, "counter", 1); err != nil {
+				t.Fatalf("cas: expected the counter to stay countable, %v", err)
+			}
+
+			if val := c.Get(ctx, "name").Val(); val != "fuxiao" {
+				t.Fatalf("cas: unexpected value %q", val)
+			}
+		})
+	}
+}
+
+func TestCache_CompareAndSwapStamp(t *testing.T) {
+	var (
+		ctx = context.Background()
+		c   = newRedisCache(t)
+		// a plain value looking like a stamped one
+		name = "\x05" + strings.Repeat("fuxiao", 8)
+	)
+
+	_ = c.Set(ctx, "name", name, time.Minute)
+	_ = c.Set(ctx, "counter", "10", time.Minute)
+
+	for _, key := range []string{"name", "counter"} {
+		if rst, _ := c.GetWithVersion(ctx, key); rst.Err() != nil {
+			t.Fatal(rst.Err())
+		}
+	}
+
+	if val, err := c.Get(ctx, "name").Result(); err != nil || val != name {
+		t.Fatalf("cas: expected the stamped value to read as stored, got %q, %v", val, err)
+	}
+
+	if val, err := c.Increment(ctx, "counter", 1); err != nil || val != 11 {
+		t.Fatalf("cas: expected the counter to stay countable, got %v, %v", val, err)
+	}
+}
+
+func TestCache_Update(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory": cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":  newRedisCache(t),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				wg  sync.WaitGroup
+			)
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					err := c.Update(ctx, "counter", time.Minute, func(old cache.Result) (interface{}, error) {
+						if old.Err() == cache.Nil {
+							return 1, nil
+						}
+
+						n, err := old.Int()
+						return n + 1, err
+					})
+					if err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n, err := c.Get(ctx, "counter").Int(); err != nil || n != 10 {
+				t.Fatalf("update: expected no update to be lost, got %d, %v", n, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 11:20 下午
+ * @Desc: value codec define
+ */
+
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/dobyte/cache/internal/conv"
+)
+
+// codecMarker The first byte of a payload encoded by a codec, followed by the id of the codec.
+const codecMarker = '\x00'
+
+// escapeMarker The first byte of a plain value starting with a marker byte, followed by the value,
+// so that the plain value is never taken for a payload of a codec, a compressor, a keyring, the metadata
+// or a revision stamp.
+const escapeMarker = '\x04'
+
+const (
+	JSONCodecID    byte = 'j'
+	MsgpackCodecID byte = 'm'
+	GobCodecID     byte = 'g'
+	ProtoCodecID   byte = 'p'
+)
+
+// encodedValue A value already encoded by encodeValue, which is stored as is.
+type encodedValue string
+
+type Codec interface {
+	// ID Return the unique id of the codec carried by the encoded payloads.
+	ID() byte
+	// Marshal Encode a value into bytes.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal Decode bytes into a value.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type (
+	JSONCodec    struct{}
+	MsgpackCodec struct{}
+	GobCodec     struct{}
+	ProtoCodec   struct{}
+)
+
+var codecs sync.Map
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(MsgpackCodec{})
+	RegisterCodec(GobCodec{})
+	RegisterCodec(ProtoCodec{})
+}
+
+// RegisterCodec Register a codec so that the payloads carrying its id can be decoded whichever codec a store writes with.
+func RegisterCodec(codec Codec) {
+	codecs.Store(codec.ID(), codec)
+}
+
+// ID Return the unique id of the codec.
+func (JSONCodec) ID() byte { return JSONCodecID }
+
+// Marshal Encode a value into json.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal Decode json into a value.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// ID Return the unique id of the codec.
+func (MsgpackCodec) ID() byte { return MsgpackCodecID }
+
+// Marshal Encode a value into msgpack.
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+
+// Unmarshal Decode msgpack into a value.
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+
+// ID Return the unique id of the codec.
+func (GobCodec) ID() byte { return GobCodecID }
+
+// Marshal Encode a value into gob.
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal Decode gob into a value.
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ID Return the unique id of the codec.
+func (ProtoCodec) ID() byte { return ProtoCodecID }
+
+// Marshal Encode a proto message into protobuf.
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cache: can't marshal %T, not a proto message", v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+// Unmarshal Decode protobuf into a proto message.
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cache: can't unmarshal %T, not a proto message", v)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+// encodeValue Encode a value with the codec, the scalars are kept as plain strings so that they stay
+// readable and countable, and all values are converted by conv.String when there is no codec.
+// The metadata of a value wrapped along with it is put in front of the encoded value.
+func encodeValue(codec Codec, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case encodedValue:
+		return string(v), nil
+	case *metaValue:
+		val, err := encodeValue(codec, v.value)
+		if err != nil {
+			return "", err
+		}
+
+		return wrapMeta(val, v.meta), nil
+	}
+
+	if codec == nil || isScalar(value) {
+		return escapeValue(conv.String(value)), nil
+	}
+
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 0, len(data)+2)
+	buf = append(buf, codecMarker, codec.ID())
+	buf = append(buf, data...)
+
+	return string(buf), nil
+}
+
+// scanValue Decode a value with the codec its payload carries, or convert it by conv.Scan if it carries none.
+func scanValue(val string, v interface{}) error {
+	if len(val) < 2 || val[0] != codecMarker {
+		return conv.Scan([]byte(val), v)
+	}
+
+	codec, ok := codecs.Load(val[1])
+	if !ok {
+		return fmt.Errorf("cache: unknown codec %q", val[1])
+	}
+
+	return codec.(Codec).Unmarshal([]byte(val[2:]), v)
+}
+
+// escapeValue Put the escape marker in front of a plain value starting with a marker byte.
+func escapeValue(val string) string {
+	if len(val) == 0 || val[0] > revisionMarker {
+		return val
+	}
+
+	return string(escapeMarker) + val
+}
+
+// unescapeValue Remove the escape marker from a plain value, report whether the value is plain
+// rather than a payload of a codec.
+func unescapeValue(val string) (string, bool) {
+	switch {
+	case len(val) == 0:
+		return val, true
+	case val[0] == escapeMarker:
+		return val[1:], true
+	default:
+		return val, val[0] != codecMarker
+	}
+}
+
+// isInteger Determine if a value is an integer, which is kept in plain as a counter.
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+
+	return false
+}
+
+// isScalar Determine if a value is a scalar stored as a plain string.
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, []byte, bool, time.Time, *time.Time,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,163 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 11:50 下午
+ * @Desc: codec test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/dobyte/cache"
+)
+
+type codecStudent struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// String A String method must not override the codec.
+func (s codecStudent) String() string {
+	return s.Name
+}
+
+func TestCodec(t *testing.T) {
+	ctx := context.Background()
+
+	for name, codec := range map[string]cache.Codec{
+		"json":    cache.JSONCodec{},
+		"msgpack": cache.MsgpackCodec{},
+		"gob":     cache.GobCodec{},
+	} {
+		store := newMemoryStore(&cache.MemoryOptions{Prefix: "cache", Codec: codec, CleanupInterval: -1})
+
+		if err := store.Set(ctx, "student", codecStudent{Name: "fuxiao", Age: 30}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+
+		var s codecStudent
+		if err := store.Get(ctx, "student").Scan(&s); err != nil || s.Name != "fuxiao" || s.Age != 30 {
+			t.Fatalf("%s: unexpected value %+v, %v", name, s, err)
+		}
+
+		_ = store.Set(ctx, "count", 1, time.Minute)
+		if val, err := store.Increment(ctx, "count", 1); err != nil || val != 2 {
+			t.Fatalf("%s: expected the scalars to bypass the codec, got %d, %v", name, val, err)
+		}
+	}
+}
+
+func TestCodec_Proto(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{Codec: cache.ProtoCodec{}, CleanupInterval: -1})
+		msg   = &wrapperspb.StringValue{}
+	)
+
+	if err := store.Set(ctx, "msg", wrapperspb.String("fuxiao"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Get(ctx, "msg").Scan(msg); err != nil || msg.GetValue() != "fuxiao" {
+		t.Fatalf("proto: unexpected value %q, %v", msg.GetValue(), err)
+	}
+
+	if err := store.Set(ctx, "student", codecStudent{Name: "fuxiao"}, time.Minute); err == nil {
+		t.Fatal("proto: expected an error for a value which is not a proto message")
+	}
+}
+
+func TestCodec_Migration(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{Codec: cache.GobCodec{}, CleanupInterval: -1})
+		s     codecStudent
+	)
+
+	_ = store.Set(ctx, "old", codecStudent{Name: "gob"}, time.Minute)
+
+	store.SetCodec(cache.MsgpackCodec{})
+	_ = store.Set(ctx, "new", codecStudent{Name: "msgpack"}, time.Minute)
+
+	if err := store.Get(ctx, "old").Scan(&s); err != nil || s.Name != "gob" {
+		t.Fatalf("codec: expected the old entry to be decoded with its own codec, got %+v, %v", s, err)
+	}
+
+	if err := store.Get(ctx, "new").Scan(&s); err != nil || s.Name != "msgpack" {
+		t.Fatalf("codec: unexpected value %+v, %v", s, err)
+	}
+
+	store.SetCodec(nil)
+	_ = store.Set(ctx, "plain", codecStudent{Name: "plain"}, time.Minute)
+
+	if val := store.Get(ctx, "plain").Val(); val != "plain" {
+		t.Fatalf("codec: expected the legacy conversion without a codec, got %q", val)
+	}
+}
+
+func TestCodec_MarkerBytes(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		values  = []string{"\x00jnull", "\x01g", "\x02\x01a", "\x03\x03\x01\x02\x03fuxiao", "\x04", "\x00", ""}
+		keyring = newKeyring(t, "a", "a")
+	)
+
+	for driver, store := range map[string]cache.Store{
+		"memory":    newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}),
+		"redis":     newRedisStore(t, &cache.RedisOptions{Codec: cache.JSONCodec{}, Compressor: cache.GzipCompressor{}, CompressThreshold: 1}),
+		"memcached": newMemcachedStore(t, &cache.MemcachedOptions{Codec: cache.MsgpackCodec{}, Keyring: keyring}),
+		"encrypted": newMemoryStore(&cache.MemoryOptions{Keyring: keyring, CleanupInterval: -1}),
+		"tiered": cache.NewTieredStore(
+			newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}),
+			newMemoryStore(&cache.MemoryOptions{Codec: cache.JSONCodec{}, CleanupInterval: -1}),
+			nil,
+		),
+	} {
+		for i, value := range values {
+			key := fmt.Sprintf("plain%d", i)
+
+			if err := store.Set(ctx, key, value, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			if val, err := store.Get(ctx, key).Result(); err != nil || val != value {
+				t.Fatalf("%s: expected %q to round trip, got %q, %v", driver, value, val, err)
+			}
+
+			var s string
+			if err := store.Get(ctx, key).Scan(&s); err != nil || s != value {
+				t.Fatalf("%s: expected %q to be scanned, got %q, %v", driver, value, s, err)
+			}
+
+			if err := store.Set(ctx, key, []byte(value), time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			if b, err := store.Get(ctx, key).Bytes(); err != nil || string(b) != value {
+				t.Fatalf("%s: expected the bytes %q to round trip, got %q, %v", driver, value, b, err)
+			}
+
+			rst := store.GetSet(ctx, "loaded"+key, func() (interface{}, time.Duration, error) {
+				return value, time.Minute, nil
+			})
+			if val, err := rst.Result(); err != nil || val != value {
+				t.Fatalf("%s: expected the loaded %q to round trip, got %q, %v", driver, value, val, err)
+			}
+
+			if val, err := store.Get(ctx, "loaded"+key).Result(); err != nil || val != value {
+				t.Fatalf("%s: expected the stored %q to round trip, got %q, %v", driver, value, val, err)
+			}
+		}
+	}
+
+	if val := cache.NewResult("\x03\x01\x05fuxiao").Val(); val != "\x03\x01\x05fuxiao" {
+		t.Fatalf("result: expected a plain value to be kept as is, got %q", val)
+	}
+}
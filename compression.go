@@ -0,0 +1,200 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 12:20 上午
+ * @Desc: value compression define
+ */
+
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressMarker The first byte of a compressed payload, followed by the id of the compressor.
+const compressMarker = '\x01'
+
+// defaultCompressThreshold The values shorter than the threshold are stored uncompressed.
+const defaultCompressThreshold = 1024
+
+const (
+	GzipCompressorID   byte = 'g'
+	SnappyCompressorID byte = 's'
+	ZstdCompressorID   byte = 'z'
+)
+
+type Compressor interface {
+	// ID Return the unique id of the compressor carried by the compressed payloads.
+	ID() byte
+	// Compress Compress the data.
+	Compress(data []byte) ([]byte, error)
+	// Decompress Decompress the data.
+	Decompress(data []byte) ([]byte, error)
+}
+
+type (
+	GzipCompressor struct {
+		// Level The gzip compression level, zero means the default compression.
+		Level int
+	}
+	SnappyCompressor struct{}
+	ZstdCompressor   struct{}
+)
+
+var (
+	compressors sync.Map
+
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func init() {
+	RegisterCompressor(GzipCompressor{})
+	RegisterCompressor(SnappyCompressor{})
+	RegisterCompressor(ZstdCompressor{})
+}
+
+// RegisterCompressor Register a compressor so that the payloads carrying its id can be decompressed.
+func RegisterCompressor(compressor Compressor) {
+	compressors.Store(compressor.ID(), compressor)
+}
+
+// ID Return the unique id of the compressor.
+func (GzipCompressor) ID() byte { return GzipCompressorID }
+
+// Compress Compress the data with gzip.
+func (c GzipCompressor) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress Decompress the gzip data.
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// ID Return the unique id of the compressor.
+func (SnappyCompressor) ID() byte { return SnappyCompressorID }
+
+// Compress Compress the data with snappy.
+func (SnappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+// Decompress Decompress the snappy data.
+func (SnappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// ID Return the unique id of the compressor.
+func (ZstdCompressor) ID() byte { return ZstdCompressorID }
+
+// Compress Compress the data with zstd.
+func (ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+// Decompress Decompress the zstd data.
+func (ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+
+	return zstdDecoder.DecodeAll(data, nil)
+}
+
+// initZstd Create the shared zstd encoder and decoder, both are safe for concurrent use of EncodeAll and DecodeAll.
+func initZstd() (err error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, err = zstd.NewWriter(nil); err != nil {
+			return
+		}
+
+		zstdDecoder, err = zstd.NewReader(nil)
+	})
+
+	if err == nil && (zstdEncoder == nil || zstdDecoder == nil) {
+		err = fmt.Errorf("cache: zstd is unavailable")
+	}
+
+	return
+}
+
+// compressValue Compress a value with the compressor if it's not shorter than the threshold,
+// the value is kept as is if the compression doesn't make it shorter.
+func compressValue(compressor Compressor, threshold int, val string) (string, error) {
+	if compressor == nil || len(val) < threshold {
+		return val, nil
+	}
+
+	data, err := compressor.Compress([]byte(val))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data)+2 >= len(val) {
+		return val, nil
+	}
+
+	buf := make([]byte, 0, len(data)+2)
+	buf = append(buf, compressMarker, compressor.ID())
+	buf = append(buf, data...)
+
+	return string(buf), nil
+}
+
+// decompressValue Decompress a value with the compressor its payload carries, the plain values are returned as is.
+func decompressValue(val string) (string, error) {
+	if len(val) < 2 || val[0] != compressMarker {
+		return val, nil
+	}
+
+	compressor, ok := compressors.Load(val[1])
+	if !ok {
+		return "", fmt.Errorf("cache: unknown compressor %q", val[1])
+	}
+
+	data, err := compressor.(Compressor).Decompress([]byte(val[2:]))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
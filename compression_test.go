@@ -0,0 +1,72 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 12:50 上午
+ * @Desc: compression test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestCompressor(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		large = strings.Repeat("fuxiao", 100)
+	)
+
+	for name, compressor := range map[string]cache.Compressor{
+		"gzip":   cache.GzipCompressor{},
+		"snappy": cache.SnappyCompressor{},
+		"zstd":   cache.ZstdCompressor{},
+	} {
+		store := newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		store.SetCompressor(compressor, 64)
+
+		_ = store.Set(ctx, "large", large, time.Minute)
+
+		if store.Size() >= int64(len(large)) {
+			t.Fatalf("%s: expected the large value to be compressed, got %d bytes", name, store.Size())
+		}
+
+		if val, err := store.Get(ctx, "large").Result(); err != nil || val != large {
+			t.Fatalf("%s: unexpected value, %v", name, err)
+		}
+
+		size := store.Size()
+		_ = store.Set(ctx, "small", "fuxiao", time.Minute)
+
+		if n := store.Size() - size; n != int64(len(store.PrefixKey("small"))+len("fuxiao")) {
+			t.Fatalf("%s: expected the small value to be stored plain, got %d bytes", name, n)
+		}
+
+		rst, _ := store.GetMany(ctx, "large", "small")
+		if rst["large"].Val() != large || rst["small"].Val() != "fuxiao" {
+			t.Fatalf("%s: expected compressed and plain values to live side by side", name)
+		}
+	}
+}
+
+func TestCompressor_Switch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		large = strings.Repeat("fuxiao", 100)
+	)
+
+	store.SetCompressor(cache.GzipCompressor{}, 0)
+	_ = store.Set(ctx, "old", large, time.Minute)
+
+	store.SetCompressor(nil, 0)
+
+	if val := store.Get(ctx, "old").Val(); val != large {
+		t.Fatal("compressor: expected the compressed value to be readable after disabling the compression")
+	}
+}
@@ -7,10 +7,61 @@
 
 package cache
 
-const Nil = StoreError("store: nil")
+import (
+	"fmt"
+	"time"
+
+	"github.com/dobyte/cache/internal/sync"
+)
+
+const (
+	Nil              = StoreError("store: nil")
+	ErrNotInteger    = StoreError("store: value is not an integer")
+	ErrValueTooLarge = StoreError("store: value too large")
+	ErrLockLost      = StoreError("lock: lost")
+	ErrInvalidTTL    = StoreError("lock: non-positive ttl")
+	ErrCASConflict   = StoreError("store: too many compare-and-swap conflicts")
+	ErrTxConflict    = StoreError("store: too many transaction conflicts")
+	ErrNotExecuted   = StoreError("pipeline: not executed")
+	ErrInvalidLimit  = StoreError("rate limiter: max attempts must be positive and decay at least a millisecond")
+	errNoUpdate      = StoreError("store: no update")
+	errFound         = StoreError("store: item found")
+)
 
 type StoreError string
 
 func (e StoreError) Error() string { return string(e) }
 
 func (StoreError) StoreError() {}
+
+// LockTimeoutError Returned when a lock can't be acquired within the wait or before the context ends.
+type LockTimeoutError struct {
+	Name string
+	Wait time.Duration
+	Err  error
+}
+
+func (e *LockTimeoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("lock: failed to acquire lock %s: %v", e.Name, e.Err)
+	}
+
+	return fmt.Sprintf("lock: failed to acquire lock %s within %v", e.Name, e.Wait)
+}
+
+func (e *LockTimeoutError) Unwrap() error { return e.Err }
+
+// DecryptError Returned when a sealed value can't be opened, such as failing the authentication or missing the key.
+type DecryptError struct {
+	KeyID string
+	Err   error
+}
+
+func (e *DecryptError) Error() string {
+	return fmt.Sprintf("cache: failed to decrypt value sealed with key %s: %v", e.KeyID, e.Err)
+}
+
+func (e *DecryptError) Unwrap() error { return e.Err }
+
+// PanicError Passed on to all callers sharing a loader when the loader panics, carrying the panic value and its stack.
+type PanicError = sync.PanicError
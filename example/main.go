@@ -8,6 +8,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -22,6 +23,8 @@ type student struct {
 }
 
 func main() {
+	ctx := context.Background()
+
 	c := cache.NewCache(&cache.Options{
 		Driver: cache.RedisDriver,
 		Prefix: "cache",
@@ -39,7 +42,7 @@ func main() {
 	// If the fn function returns an error of cache.Nil,
 	// the default null value (cache@nil) will be stored in the cache for a certain period of time (10s).
 	{
-		rst1 := c.GetSet("name", func() (interface{}, time.Duration, error) {
+		rst1 := c.GetSet(ctx, "name", func() (interface{}, time.Duration, error) {
 			return "fuxiao", 10 * time.Second, nil
 		})
 		if err := rst1.Err(); err != nil && err != cache.Nil {
@@ -51,7 +54,7 @@ func main() {
 	
 	// No data found from fn function
 	{
-		rst2 := c.GetSet("fullname", func() (interface{}, time.Duration, error) {
+		rst2 := c.GetSet(ctx, "fullname", func() (interface{}, time.Duration, error) {
 			return nil, 0, cache.Nil
 		})
 		if err := rst2.Err(); err != nil && err != cache.Nil {
@@ -62,7 +65,7 @@ func main() {
 	}
 	
 	{
-		rst3 := c.GetSet("fuxiao", func() (interface{}, time.Duration, error) {
+		rst3 := c.GetSet(ctx, "fuxiao", func() (interface{}, time.Duration, error) {
 			return &student{
 				Name:     "fuxiao",
 				Age:      30,
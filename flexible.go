@@ -0,0 +1,74 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 4:00 下午
+ * @Desc: stale-while-revalidate retrieval
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dobyte/cache/internal/sync"
+)
+
+// FlexibleFunc Load the value of an item, cache.Nil may be returned to cache the missing item for a while.
+type FlexibleFunc func(ctx context.Context) (interface{}, error)
+
+// flexible Retrieve an item from the store by key. The item younger than the fresh is returned as is,
+// the item between the fresh and the stale is returned while it's refreshed in the background,
+// and the missing item is loaded and stored for the stale. The in-process refreshes of a key are
+// shared through the group if it's not nil, and the refreshes across processes are guarded by a lock.
+func flexible(ctx context.Context, store Store, group *sync.SharedCallGroup, key string, fresh, stale time.Duration, fn FlexibleFunc) Result {
+	rst := store.GetSet(ctx, key, func() (interface{}, time.Duration, error) {
+		val, err := fn(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return newMetaValue(val), stale, nil
+	})
+	if rst.Err() != nil {
+		return rst
+	}
+
+	meta, ok := metaOf(rst)
+	if !ok || meta.age() < fresh {
+		return rst
+	}
+
+	refresh := func() (interface{}, error) {
+		return nil, refreshFlexible(context.WithoutCancel(ctx), store, key, meta.writtenAt, stale, fn)
+	}
+
+	if group != nil {
+		group.DoChan("flexible:"+key, refresh)
+	} else {
+		go refresh()
+	}
+
+	return rst
+}
+
+// refreshFlexible Load the item again and store it for the stale under the lock of the key,
+// the item is left untouched if another process has refreshed it since it was written at the given time.
+func refreshFlexible(ctx context.Context, store Store, key string, writtenAt int64, stale time.Duration, fn FlexibleFunc) error {
+	_, err := store.Lock(key+":flexible", stale).Get(ctx, func() error {
+		if meta, ok := metaOf(store.Get(ctx, key)); ok && meta.writtenAt != writtenAt {
+			return nil
+		}
+
+		switch val, err := fn(ctx); err {
+		case nil:
+			return store.Set(ctx, key, newMetaValue(val), stale)
+		case Nil:
+			return store.Forget(ctx, key)
+		default:
+			return err
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,71 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 4:20 下午
+ * @Desc: stale-while-revalidate retrieval test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestCache_Flexible(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory": cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":  newRedisCache(t),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				loads int32
+			)
+
+			fn := func(ctx context.Context) (interface{}, error) {
+				return fmt.Sprintf("v%d", atomic.AddInt32(&loads, 1)), nil
+			}
+
+			flexible := func() string {
+				val, err := c.Flexible(ctx, "flexible", 50*time.Millisecond, time.Minute, fn).Result()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return val
+			}
+
+			if val := flexible(); val != "v1" {
+				t.Fatalf("flexible: expected the missing item to be loaded, got %q", val)
+			}
+
+			if val := flexible(); val != "v1" || atomic.LoadInt32(&loads) != 1 {
+				t.Fatalf("flexible: expected the fresh item to be served, got %q", val)
+			}
+
+			time.Sleep(60 * time.Millisecond)
+
+			if val := flexible(); val != "v1" {
+				t.Fatalf("flexible: expected the stale item to be served, got %q", val)
+			}
+
+			for deadline := time.Now().Add(time.Second); c.Get(ctx, "flexible").Val() != "v2"; {
+				if time.Now().After(deadline) {
+					t.Fatalf("flexible: expected the stale item to be refreshed, got %q", c.Get(ctx, "flexible").Val())
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			if val := flexible(); val != "v2" || atomic.LoadInt32(&loads) != 2 {
+				t.Fatalf("flexible: expected the refreshed item to be served, got %q after %d loads", val, loads)
+			}
+		})
+	}
+}
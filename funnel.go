@@ -0,0 +1,113 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 10:30 下午
+ * @Desc: a concurrency funnel limiting the jobs running at the same moment
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultFunnelLimit        = 1
+	defaultFunnelReleaseAfter = time.Minute
+	defaultFunnelWait         = 3 * time.Second
+)
+
+type Funnel struct {
+	cache        Cache
+	name         string
+	limit        int
+	releaseAfter time.Duration
+	wait         time.Duration
+}
+
+// newFunnel Create a funnel instance, each of the slots is a lock expiring after the release time,
+// so that the slot of a crashed holder frees itself.
+func newFunnel(c Cache, name string) *Funnel {
+	return &Funnel{
+		cache:        c,
+		name:         name,
+		limit:        defaultFunnelLimit,
+		releaseAfter: defaultFunnelReleaseAfter,
+		wait:         defaultFunnelWait,
+	}
+}
+
+// Limit Set the maximum number of jobs running at the same moment.
+func (f *Funnel) Limit(limit int) *Funnel {
+	f.limit = limit
+	return f
+}
+
+// ReleaseAfter Set the time after which a slot is released even if its holder never releases it.
+func (f *Funnel) ReleaseAfter(releaseAfter time.Duration) *Funnel {
+	f.releaseAfter = releaseAfter
+	return f
+}
+
+// Block Set the time to wait for a free slot.
+func (f *Funnel) Block(wait time.Duration) *Funnel {
+	f.wait = wait
+	return f
+}
+
+// Then Run the callback while holding a slot and release the slot afterwards.
+// If no slot frees up within the wait, the failure callback is called with a *LockTimeoutError,
+// or the error is returned if there is no failure callback.
+func (f *Funnel) Then(ctx context.Context, fn func() error, onFail ...func(err error) error) error {
+	var slot Lock
+
+	err := blockAcquire(ctx, f.name, f.wait, func(ctx context.Context) (bool, error) {
+		for i := 0; i < f.limit; i++ {
+			lock := f.cache.Lock(f.slotName(i), f.releaseAfter)
+			if ok, err := lock.Acquire(ctx); err != nil || ok {
+				slot = lock
+				return ok, err
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		if _, ok := err.(*LockTimeoutError); ok && len(onFail) > 0 {
+			return onFail[0](err)
+		}
+
+		return err
+	}
+
+	return runLocked(ctx, slot.Release, []func() error{fn})
+}
+
+// InUse Get the number of slots held at the moment.
+func (f *Funnel) InUse(ctx context.Context) (int, error) {
+	names := make([]string, f.limit)
+	for i := range names {
+		names[i] = f.slotName(i)
+	}
+
+	rst, err := f.cache.HasMany(ctx, names...)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, ok := range rst {
+		if ok {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// slotName Get the lock name of a slot.
+func (f *Funnel) slotName(i int) string {
+	return fmt.Sprintf("%s:slot:%d", f.name, i)
+}
@@ -0,0 +1,105 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 10:50 下午
+ * @Desc: funnel test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestFunnel(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		c                = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"})
+		running, maximum int32
+		wg               sync.WaitGroup
+	)
+
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := c.Funnel("jobs").Limit(2).Block(time.Second).Then(ctx, func() error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+
+				for {
+					m := atomic.LoadInt32(&maximum)
+					if n <= m || atomic.CompareAndSwapInt32(&maximum, m, n) {
+						break
+					}
+				}
+
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if maximum != 2 {
+		t.Fatalf("funnel: expected at most 2 jobs at once, got %d", maximum)
+	}
+
+	if n, _ := c.Funnel("jobs").Limit(2).InUse(ctx); n != 0 {
+		t.Fatalf("funnel: expected the slots to be released, got %d in use", n)
+	}
+}
+
+func TestFunnel_Fail(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		c       = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"})
+		release = make(chan struct{})
+		held    = make(chan struct{})
+	)
+
+	go func() {
+		_ = c.Funnel("jobs").Then(ctx, func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+	defer close(release)
+
+	if n, _ := c.Funnel("jobs").InUse(ctx); n != 1 {
+		t.Fatalf("funnel: expected 1 slot in use, got %d", n)
+	}
+
+	var failed bool
+	err := c.Funnel("jobs").Block(30*time.Millisecond).Then(ctx, func() error {
+		t.Fatal("funnel: expected the callback not to run")
+		return nil
+	}, func(err error) error {
+		var timeoutErr *cache.LockTimeoutError
+		failed = errors.As(err, &timeoutErr)
+		return nil
+	})
+	if err != nil || !failed {
+		t.Fatalf("funnel: expected the failure callback with a timeout error, got %v", err)
+	}
+
+	if err = c.Funnel("stale").ReleaseAfter(20*time.Millisecond).Block(0).Then(ctx, func() error {
+		return c.Funnel("stale").Block(50*time.Millisecond).Then(ctx, func() error { return nil })
+	}); err != nil {
+		t.Fatalf("funnel: expected the expired slot to free itself, got %v", err)
+	}
+}
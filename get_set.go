@@ -0,0 +1,199 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 4:50 下午
+ * @Desc: get set options define
+ */
+
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/dobyte/cache/internal/sync"
+)
+
+// defaultGetSetLockTime The default time of the lock held while running the loader of GetSet.
+const defaultGetSetLockTime = 10 * time.Second
+
+// GetSetOptions The options of a single GetSet call.
+type GetSetOptions struct {
+	// Beta The factor of the probabilistic early expiration (XFetch), a positive beta enables it.
+	// The loader duration and the logical expiry are stored alongside the value, and each reader recomputes
+	// the value before it expires with a probability rising as the expiry approaches, so that the processes
+	// don't all miss at once. 1 is a reasonable default, a larger beta favors recomputing earlier.
+	Beta float64
+	// LockWait The wait budget of the single flight across processes on a miss, a positive wait enables it.
+	// The process holding the store's lock of the key runs the loader, the others poll with backoff until
+	// the item appears, and run the loader themselves once the wait runs out.
+	LockWait time.Duration
+	// LockTime The time of the lock held while running the loader, default 10 seconds.
+	LockTime time.Duration
+}
+
+// getSet Retrieve or set an item from the store by key with the options. The early recomputes of a key
+// in the process are shared through the group if it's not nil, and the cached item is returned
+// if an early recompute fails. The item found by a process waiting for the lock is read again
+// so that the cached nil value is reported as cache.Nil.
+func getSet(ctx context.Context, store Store, group *sync.SharedCallGroup, key string, fn defaultValueFunc, opt ...*GetSetOptions) Result {
+	if len(opt) == 0 || opt[0] == nil || (opt[0].Beta <= 0 && opt[0].LockWait <= 0) {
+		return store.GetSet(ctx, key, fn)
+	}
+
+	o := opt[0]
+
+	load := fn
+	if o.Beta > 0 {
+		load = xfetchLoader(fn)
+	}
+
+	var rst Result
+	if o.LockWait > 0 {
+		loader, release := lockedLoader(ctx, store, key, o, load)
+		rst = store.GetSet(ctx, key, loader)
+		release()
+
+		if rst.Err() == errFound {
+			rst = store.GetSet(ctx, key, load)
+		}
+	} else {
+		rst = store.GetSet(ctx, key, load)
+	}
+
+	if rst.Err() != nil || o.Beta <= 0 {
+		return rst
+	}
+
+	if meta, ok := metaOf(rst); !ok || !meta.expiresEarly(o.Beta) {
+		return rst
+	}
+
+	recompute := func() (interface{}, error) {
+		val, expire, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = store.Set(ctx, key, val, expire); err != nil {
+			return nil, err
+		}
+
+		return store.Get(ctx, key), nil
+	}
+
+	var (
+		val interface{}
+		err error
+	)
+
+	if group != nil {
+		val, err = group.Call(ctx, "xfetch:"+key, recompute)
+	} else {
+		val, err = recompute()
+	}
+
+	if err != nil {
+		return rst
+	}
+
+	return val.(Result)
+}
+
+// xfetchLoader Wrap a loader to store the value along with the time it took to load and its logical expiry.
+func xfetchLoader(fn defaultValueFunc) defaultValueFunc {
+	return func() (interface{}, time.Duration, error) {
+		start := time.Now()
+
+		val, expire, err := fn()
+		if err != nil {
+			return val, expire, err
+		}
+
+		return newXFetchValue(val, time.Since(start), expire), expire, nil
+	}
+}
+
+// lockedLoader Wrap a loader to run only in the process holding the store's lock of the key. The others poll
+// with backoff until the item appears, in which case errFound is returned for the item to be read again,
+// or until the wait runs out, in which case they run the loader themselves. The returned release must be called
+// once the store wrote the loaded item, so that the lock is held until the waiters can find the item.
+func lockedLoader(ctx context.Context, store Store, key string, o *GetSetOptions, fn defaultValueFunc) (defaultValueFunc, func()) {
+	held := make(chan Lock, 1)
+
+	loader := func() (interface{}, time.Duration, error) {
+		lockTime := o.LockTime
+		if lockTime <= 0 {
+			lockTime = defaultGetSetLockTime
+		}
+
+		var (
+			lock     = store.Lock(key+":getset", lockTime)
+			acquired bool
+		)
+
+		err := blockAcquire(ctx, key, o.LockWait, func(ctx context.Context) (bool, error) {
+			ok, err := lock.Acquire(ctx)
+			if err != nil || ok {
+				acquired = ok
+				return ok, err
+			}
+
+			return store.Has(ctx, key)
+		})
+
+		switch {
+		case acquired:
+			held <- lock
+
+			if ok, err := store.Has(ctx, key); err == nil && ok {
+				return nil, 0, errFound
+			}
+		case err == nil:
+			return nil, 0, errFound
+		case ctx.Err() != nil:
+			return nil, 0, ctx.Err()
+		}
+
+		return fn()
+	}
+
+	release := func() {
+		select {
+		case lock := <-held:
+			_, _ = lock.Release(context.WithoutCancel(ctx))
+		default:
+		}
+	}
+
+	return loader, release
+}
+
+// newXFetchValue Wrap a value to be stored along with the time it took to load and its logical expiry.
+// The time it took to load is rounded up to at least a millisecond, so that a fast loader still recomputes early.
+func newXFetchValue(value interface{}, delta time.Duration, expire time.Duration) *metaValue {
+	mv := newMetaValue(value)
+	mv.meta.delta = int64((delta + time.Millisecond - 1) / time.Millisecond)
+	if mv.meta.delta < 1 {
+		mv.meta.delta = 1
+	}
+	if expire > 0 {
+		mv.meta.expireAt = mv.meta.writtenAt + expire.Milliseconds()
+	}
+
+	return mv
+}
+
+// expiresEarly Determine if the value should be recomputed before it expires, the probability rises
+// as the expiry approaches and with the time the value took to load.
+func (m valueMeta) expiresEarly(beta float64) bool {
+	if m.expireAt == 0 {
+		return false
+	}
+
+	gap := -float64(m.delta) * beta * math.Log(1-rand.Float64())
+
+	return float64(time.Now().UnixMilli())+gap >= float64(m.expireAt)
+}
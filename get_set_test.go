@@ -0,0 +1,175 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 5:10 下午
+ * @Desc: get set options test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+type (
+	// releaseCheckedStore A store reporting whether the item of a key exists when a lock of it is released.
+	releaseCheckedStore struct {
+		cache.Store
+		key   string
+		early int32
+	}
+
+	releaseCheckedLock struct {
+		cache.Lock
+		store *releaseCheckedStore
+	}
+)
+
+func (s *releaseCheckedStore) Lock(name string, ttl time.Duration) cache.Lock {
+	return &releaseCheckedLock{Lock: s.Store.Lock(name, ttl), store: s}
+}
+
+func (l *releaseCheckedLock) Release(ctx context.Context) (bool, error) {
+	if ok, _ := l.store.Has(ctx, l.store.key); !ok {
+		atomic.AddInt32(&l.store.early, 1)
+	}
+
+	return l.Lock.Release(ctx)
+}
+
+func TestCache_GetSetXFetch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		c     = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"})
+		loads int
+	)
+
+	fn := func() (interface{}, time.Duration, error) {
+		loads++
+		time.Sleep(5 * time.Millisecond)
+		return fmt.Sprintf("v%d", loads), time.Minute, nil
+	}
+
+	if val := c.GetSet(ctx, "xfetch", fn, &cache.GetSetOptions{Beta: 1}).Val(); val != "v1" {
+		t.Fatalf("xfetch: expected the missing item to be loaded, got %q", val)
+	}
+
+	if val := c.GetSet(ctx, "xfetch", fn, &cache.GetSetOptions{Beta: 1e-9}).Val(); val != "v1" || loads != 1 {
+		t.Fatalf("xfetch: expected the item far from its expiry to be served, got %q", val)
+	}
+
+	if val := c.GetSet(ctx, "xfetch", fn).Val(); val != "v1" || loads != 1 {
+		t.Fatalf("xfetch: expected the item to be served without the options, got %q", val)
+	}
+
+	if val := c.GetSet(ctx, "xfetch", fn, &cache.GetSetOptions{Beta: 1e12}).Val(); val != "v2" || loads != 2 {
+		t.Fatalf("xfetch: expected the item to be recomputed early, got %q after %d loads", val, loads)
+	}
+
+	if val := c.Get(ctx, "xfetch").Val(); val != "v2" {
+		t.Fatalf("xfetch: expected the recomputed item to be stored, got %q", val)
+	}
+}
+
+func TestCache_GetSetXFetchFastLoader(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		c     = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"})
+		loads int
+	)
+
+	// a loader returning in under a millisecond still records a delta to recompute early with
+	fn := func() (interface{}, time.Duration, error) {
+		loads++
+		return fmt.Sprintf("v%d", loads), time.Minute, nil
+	}
+
+	_ = c.GetSet(ctx, "xfetch", fn, &cache.GetSetOptions{Beta: 1})
+
+	if val := c.GetSet(ctx, "xfetch", fn, &cache.GetSetOptions{Beta: 1e12}).Val(); val != "v2" || loads != 2 {
+		t.Fatalf("xfetch: expected the item of a fast loader to be recomputed early, got %q after %d loads", val, loads)
+	}
+}
+
+func TestCache_GetSetLock(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		addr  = miniredis.RunT(t).Addr()
+		loads int32
+		wg    sync.WaitGroup
+	)
+
+	fn := func() (interface{}, time.Duration, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "fuxiao", time.Minute, nil
+	}
+
+	// each cache has its own store, standing for a process sharing the redis server
+	for i := 0; i < 5; i++ {
+		c := cache.NewCache(&cache.Options{
+			Driver: cache.RedisDriver,
+			Prefix: "cache",
+			Stores: cache.Stores{Redis: &cache.RedisOptions{Addrs: []string{addr}}},
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if val := c.GetSet(ctx, "name", fn, &cache.GetSetOptions{LockWait: time.Second}).Val(); val != "fuxiao" {
+				t.Errorf("lock: unexpected value %q", val)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if loads != 1 {
+		t.Fatalf("lock: expected the loader to run once across the stores, ran %d times", loads)
+	}
+
+	var (
+		c    = newRedisCache(t)
+		hold = c.Lock("missing:getset", time.Minute)
+	)
+
+	if ok, err := hold.Acquire(ctx); err != nil || !ok {
+		t.Fatalf("lock: failed to acquire the lock, %v", err)
+	}
+
+	rst := c.GetSet(ctx, "missing", func() (interface{}, time.Duration, error) {
+		return nil, 0, cache.Nil
+	}, &cache.GetSetOptions{LockWait: 30 * time.Millisecond})
+	if rst.Err() != cache.Nil {
+		t.Fatalf("lock: expected the loader to run once the wait runs out, got %v", rst.Err())
+	}
+}
+
+func TestCache_GetSetLockReleasedAfterWrite(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = &releaseCheckedStore{Store: cache.NewMemoryStore(&cache.MemoryOptions{Prefix: "cache"}), key: "name"}
+		c     = cache.NewCacheWithStore(store)
+	)
+
+	rst := c.GetSet(ctx, "name", func() (interface{}, time.Duration, error) {
+		return "fuxiao", time.Minute, nil
+	}, &cache.GetSetOptions{LockWait: time.Second})
+	if rst.Val() != "fuxiao" {
+		t.Fatalf("lock: unexpected value %q, %v", rst.Val(), rst.Err())
+	}
+
+	// a waiter acquiring the lock before the item is written would run the loader again
+	if store.early != 0 {
+		t.Fatal("lock: expected the lock to be released after the item is written")
+	}
+}
@@ -7,63 +7,164 @@
 
 package sync
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
 
 type (
 	sharedCall struct {
-		wg  sync.WaitGroup
-		val interface{}
-		err error
+		chans []chan<- Result
 	}
-	
-	sharedCallGroup struct {
+
+	SharedCallGroup struct {
+		prefix string
 		calls  map[string]*sharedCall
 		locker sync.Mutex
+		total  int64
+		shared int64
+	}
+
+	// Result The result of a shared call.
+	Result struct {
+		Val interface{}
+		Err error
+		// Shared Report whether the result was delivered to more than one caller.
+		Shared bool
+	}
+
+	// Stats The statistics of a shared call group.
+	Stats struct {
+		// Calls The number of calls made to the group.
+		Calls int64
+		// Shared The number of calls which joined a call already in flight instead of running their own.
+		Shared int64
+	}
+
+	// PanicError The error delivered to the callers when the function of a shared call panics.
+	PanicError struct {
+		Value interface{}
+		Stack []byte
 	}
-	
+
 	CallFunc func() (interface{}, error)
 )
 
-func NewSharedCallGroup() *sharedCallGroup {
-	return &sharedCallGroup{
-		calls: make(map[string]*sharedCall),
+// NewSharedCallGroup Create a shared call group, the keys are namespaced by the prefix
+// the same way as the keys of the store owning the group.
+func NewSharedCallGroup(prefix string) *SharedCallGroup {
+	return &SharedCallGroup{
+		prefix: prefix,
+		calls:  make(map[string]*sharedCall),
 	}
 }
 
-func (s *sharedCallGroup) Call(key string, fn func() (interface{}, error)) (interface{}, error) {
-	call, done := s.createCall(key)
-	if done {
-		return call.val, call.err
+// Call Run the fn once for the concurrent callers of a key and return its result to all of them.
+// Each caller may abandon the wait through its own context without affecting the others.
+// If the fn panics, the panic is passed on to all callers as a *PanicError.
+func (s *SharedCallGroup) Call(ctx context.Context, key string, fn CallFunc) (interface{}, error) {
+	select {
+	case ret := <-s.DoChan(key, fn):
+		if p, ok := ret.Err.(*PanicError); ok {
+			panic(p)
+		}
+
+		return ret.Val, ret.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	
-	s.makeCall(key, call, fn)
-	
-	return call.val, call.err
 }
 
-func (s *sharedCallGroup) createCall(key string) (*sharedCall, bool) {
+// DoChan Run the fn once for the concurrent callers of a key, the result is delivered on the returned channel.
+// If the fn panics, the result carries a *PanicError.
+func (s *SharedCallGroup) DoChan(key string, fn CallFunc) <-chan Result {
+	ch := make(chan Result, 1)
+	key = s.prefixKey(key)
+
+	atomic.AddInt64(&s.total, 1)
+
 	s.locker.Lock()
-	
 	if call, ok := s.calls[key]; ok {
+		call.chans = append(call.chans, ch)
 		s.locker.Unlock()
-		call.wg.Wait()
-		return call, true
+		atomic.AddInt64(&s.shared, 1)
+		return ch
 	}
-	
-	call := new(sharedCall)
+
+	call := &sharedCall{chans: []chan<- Result{ch}}
 	s.calls[key] = call
-	call.wg.Add(1)
 	s.locker.Unlock()
-	return call, false
+
+	go s.makeCall(key, call, fn)
+
+	return ch
+}
+
+// Forget Forget the call in flight for a key, so that the next caller runs the fn again
+// instead of waiting for the call in flight.
+func (s *SharedCallGroup) Forget(key string) {
+	s.locker.Lock()
+	delete(s.calls, s.prefixKey(key))
+	s.locker.Unlock()
 }
 
-func (s *sharedCallGroup) makeCall(key string, call *sharedCall, fn func() (interface{}, error)) {
+// Stats Get the statistics of the group.
+func (s *SharedCallGroup) Stats() Stats {
+	return Stats{
+		Calls:  atomic.LoadInt64(&s.total),
+		Shared: atomic.LoadInt64(&s.shared),
+	}
+}
+
+// makeCall Run the fn and deliver its result to all callers waiting for the call.
+func (s *SharedCallGroup) makeCall(key string, call *sharedCall, fn CallFunc) {
+	var ret Result
+
 	defer func() {
 		s.locker.Lock()
-		delete(s.calls, key)
+		if s.calls[key] == call {
+			delete(s.calls, key)
+		}
+		chans := call.chans
 		s.locker.Unlock()
-		call.wg.Done()
+
+		ret.Shared = len(chans) > 1
+		for _, ch := range chans {
+			ch <- ret
+		}
 	}()
-	
-	call.val, call.err = fn()
+
+	defer func() {
+		if r := recover(); r != nil {
+			ret.Err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	ret.Val, ret.Err = fn()
+}
+
+// prefixKey Add prefix to the front of key.
+func (s *SharedCallGroup) prefixKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+
+	return s.prefix + ":" + key
+}
+
+// Error Return the panic value along with the stack of the panicking function.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("sync: shared call panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap Return the panic value if it's an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,126 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 3:10 下午
+ * @Desc: shared call group test
+ */
+
+package sync_test
+
+import (
+	"context"
+	"errors"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache/internal/sync"
+)
+
+func TestSharedCallGroup_Call(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		group   = sync.NewSharedCallGroup("cache")
+		release = make(chan struct{})
+		calls   int32
+		wg      gosync.WaitGroup
+	)
+
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "fuxiao", nil
+	}
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if val, err := group.Call(ctx, "name", fn); err != nil || val != "fuxiao" {
+				t.Errorf("sync: unexpected result %v, %v", val, err)
+			}
+		}()
+	}
+
+	for group.Stats().Calls < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("sync: expected the fn to run once, ran %d times", calls)
+	}
+
+	if stats := group.Stats(); stats.Calls != 5 || stats.Shared != 4 {
+		t.Fatalf("sync: unexpected stats %+v", stats)
+	}
+}
+
+func TestSharedCallGroup_Context(t *testing.T) {
+	var (
+		group   = sync.NewSharedCallGroup("cache")
+		release = make(chan struct{})
+	)
+	defer close(release)
+
+	ch := group.DoChan("name", func() (interface{}, error) {
+		<-release
+		return "fuxiao", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := group.Call(ctx, "name", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("sync: expected the waiter to abandon the wait, got %v", err)
+	}
+
+	group.Forget("name")
+
+	if val, err := group.Call(context.Background(), "name", func() (interface{}, error) {
+		return "lucy", nil
+	}); err != nil || val != "lucy" {
+		t.Fatalf("sync: expected the forgotten call to run again, got %v, %v", val, err)
+	}
+
+	select {
+	case ret := <-ch:
+		t.Fatalf("sync: expected the first call to be in flight, got %+v", ret)
+	default:
+	}
+}
+
+func TestSharedCallGroup_Panic(t *testing.T) {
+	var (
+		group   = sync.NewSharedCallGroup("cache")
+		release = make(chan struct{})
+	)
+
+	ch := group.DoChan("name", func() (interface{}, error) {
+		<-release
+		panic("boom")
+	})
+
+	done := make(chan interface{})
+	go func() {
+		defer func() { done <- recover() }()
+		_, _ = group.Call(context.Background(), "name", nil)
+	}()
+
+	for group.Stats().Shared < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	var panicErr *sync.PanicError
+	if ret := <-ch; !errors.As(ret.Err, &panicErr) || panicErr.Value != "boom" || !ret.Shared {
+		t.Fatalf("sync: expected the panic to be delivered, got %+v", ret)
+	}
+
+	if r, ok := (<-done).(*sync.PanicError); !ok || r.Value != "boom" {
+		t.Fatalf("sync: expected the panic to be passed on to the waiter, got %v", r)
+	}
+}
@@ -0,0 +1,143 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 1:10 上午
+ * @Desc: a keyring sealing values with aes-gcm
+ */
+
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// sealMarker The first byte of a sealed payload, followed by the length of the key id, the key id, the nonce and the ciphertext.
+const sealMarker = '\x02'
+
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring Create a keyring sealing the values with the primary key and opening them with any of the keys,
+// so that the keys can be rotated without flushing the cache. The keys are 16, 24 or 32 bytes long
+// to select AES-128, AES-192 or AES-256, and the key ids are up to 255 bytes long.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("cache: primary key %q is missing from the keyring", primary)
+	}
+
+	k := &Keyring{
+		primary: primary,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for id, key := range keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("cache: invalid key id %q", id)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Primary Get the id of the key sealing the values.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// seal Seal a value with the primary key, the header is authenticated along with the value.
+func (k *Keyring) seal(val string) (string, error) {
+	var (
+		aead   = k.keys[k.primary]
+		header = sealHeader(k.primary)
+		buf    = make([]byte, len(header)+aead.NonceSize(), len(header)+aead.NonceSize()+len(val)+aead.Overhead())
+	)
+
+	copy(buf, header)
+
+	nonce := buf[len(header):]
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return string(aead.Seal(buf, nonce, []byte(val), header)), nil
+}
+
+// open Open a sealed value with the key it was sealed with, the values not sealed are returned as is.
+func (k *Keyring) open(val string) (string, error) {
+	id, header, body, ok := parseSealed(val)
+	if !ok {
+		return val, nil
+	}
+
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", &DecryptError{KeyID: id, Err: fmt.Errorf("unknown key")}
+	}
+
+	if len(body) < aead.NonceSize() {
+		return "", &DecryptError{KeyID: id, Err: fmt.Errorf("truncated payload")}
+	}
+
+	plain, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], header)
+	if err != nil {
+		return "", &DecryptError{KeyID: id, Err: err}
+	}
+
+	return string(plain), nil
+}
+
+// reseal Seal a value sealed with a rotated key with the primary key again, report whether it was resealed.
+func (k *Keyring) reseal(val string) (string, bool) {
+	if k == nil {
+		return "", false
+	}
+
+	if id, _, _, ok := parseSealed(val); !ok || id == k.primary {
+		return "", false
+	}
+
+	plain, err := k.open(val)
+	if err != nil {
+		return "", false
+	}
+
+	sealed, err := k.seal(plain)
+	if err != nil {
+		return "", false
+	}
+
+	return sealed, true
+}
+
+// sealHeader Build the header of the values sealed with a key.
+func sealHeader(id string) []byte {
+	header := make([]byte, 0, len(id)+2)
+	header = append(header, sealMarker, byte(len(id)))
+
+	return append(header, id...)
+}
+
+// parseSealed Split a sealed value into the key id, the header and the body, report whether it's sealed.
+func parseSealed(val string) (string, []byte, []byte, bool) {
+	if len(val) < 2 || val[0] != sealMarker || len(val) < 2+int(val[1]) {
+		return "", nil, nil, false
+	}
+
+	n := 2 + int(val[1])
+
+	return val[2:n], []byte(val[:n]), []byte(val[n:]), true
+}
@@ -0,0 +1,179 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 2:10 上午
+ * @Desc: keyring test
+ */
+
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+func newKeyring(t *testing.T, primary string, ids ...string) *cache.Keyring {
+	keys := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte(id[:1]), 32)
+	}
+
+	keyring, err := cache.NewKeyring(primary, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyring
+}
+
+func TestKeyring(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		mr    = miniredis.RunT(t)
+		store = cache.NewRedisStore(&cache.RedisOptions{Addrs: []string{mr.Addr()}, Keyring: newKeyring(t, "a", "a")})
+	)
+
+	if err := store.Set(ctx, "email", "fuxiao@example.com", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, _ := mr.Get("email"); bytes.Contains([]byte(val), []byte("fuxiao")) {
+		t.Fatal("keyring: expected the value to be sealed at rest")
+	}
+
+	if val, err := store.Get(ctx, "email").Result(); err != nil || val != "fuxiao@example.com" {
+		t.Fatalf("keyring: unexpected value %q, %v", val, err)
+	}
+
+	sealed, _ := mr.Get("email")
+	tampered := []byte(sealed)
+	tampered[len(tampered)-1] ^= 0xff
+	_ = mr.Set("email", string(tampered))
+
+	var decryptErr *cache.DecryptError
+	if err := store.Get(ctx, "email").Err(); !errors.As(err, &decryptErr) || decryptErr.KeyID != "a" {
+		t.Fatalf("keyring: expected a decrypt error for the tampered value, got %v", err)
+	}
+}
+
+func TestKeyring_Counters(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, store := range map[string]cache.Store{
+		"memory":    newMemoryStore(&cache.MemoryOptions{Keyring: newKeyring(t, "a", "a"), CleanupInterval: -1}),
+		"redis":     newRedisStore(t, &cache.RedisOptions{Keyring: newKeyring(t, "a", "a")}),
+		"memcached": newMemcachedStore(t, &cache.MemcachedOptions{Keyring: newKeyring(t, "a", "a")}),
+	} {
+		if ok, err := store.Add(ctx, "count", 1, time.Minute); err != nil || !ok {
+			t.Fatalf("%s: expected the counter to be added, got %v, %v", driver, ok, err)
+		}
+
+		if val, err := store.Increment(ctx, "count", 2); err != nil || val != 3 {
+			t.Fatalf("%s: expected the counters to stay in plain, got %d, %v", driver, val, err)
+		}
+
+		if val, err := store.Decrement(ctx, "count", 1); err != nil || val != 2 {
+			t.Fatalf("%s: expected the counters to stay in plain, got %d, %v", driver, val, err)
+		}
+
+		if val, err := store.Get(ctx, "count").Int64(); err != nil || val != 2 {
+			t.Fatalf("%s: unexpected counter %d, %v", driver, val, err)
+		}
+
+		limiter := cache.NewRateLimiter(cache.NewCacheWithStore(store))
+		for i := int64(1); i <= 2; i++ {
+			if n, err := limiter.Hit(ctx, "api", 2, time.Minute); err != nil || n != i {
+				t.Fatalf("%s: expected the rate limiter to count the attempts, got %d, %v", driver, n, err)
+			}
+		}
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{Keyring: newKeyring(t, "a", "a"), CleanupInterval: -1})
+	)
+
+	_ = store.Set(ctx, "email", "fuxiao@example.com", 50*time.Millisecond)
+
+	store.SetKeyring(newKeyring(t, "b", "a", "b"))
+
+	if val := store.Get(ctx, "email").Val(); val != "fuxiao@example.com" {
+		t.Fatalf("keyring: expected the value sealed with the rotated key to be opened, got %q", val)
+	}
+
+	store.SetKeyring(newKeyring(t, "b", "b"))
+
+	if val, err := store.Get(ctx, "email").Result(); err != nil || val != "fuxiao@example.com" {
+		t.Fatalf("keyring: expected the value to be sealed with the primary key on read, got %q, %v", val, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := store.Get(ctx, "email").Err(); err != cache.Nil {
+		t.Fatalf("keyring: expected the resealed value to keep its expiration, got %v", err)
+	}
+}
+
+func TestKeyring_GetSet(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = cache.NewRedisStore(&cache.RedisOptions{
+			Addrs:   []string{miniredis.RunT(t).Addr()},
+			Codec:   cache.JSONCodec{},
+			Keyring: newKeyring(t, "a", "a"),
+		})
+	)
+
+	var s codecStudent
+	if err := store.GetSet(ctx, "student", func() (interface{}, time.Duration, error) {
+		return codecStudent{Name: "fuxiao"}, time.Minute, nil
+	}).Scan(&s); err != nil || s.Name != "fuxiao" {
+		t.Fatalf("keyring: expected the loaded value, got %+v, %v", s, err)
+	}
+
+	s = codecStudent{}
+	if err := store.Get(ctx, "student").Scan(&s); err != nil || s.Name != "fuxiao" {
+		t.Fatalf("keyring: expected the value to be sealed once, got %+v, %v", s, err)
+	}
+}
+
+func TestKeyring_MemoryNil(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{Keyring: newKeyring(t, "a", "a"), CleanupInterval: -1})
+		calls int
+	)
+
+	fn := func() (interface{}, time.Duration, error) {
+		calls++
+		return nil, time.Minute, cache.Nil
+	}
+
+	// the cached nil value is sealed like the others, it's recognized once opened
+	for i := 0; i < 2; i++ {
+		if rst := store.GetSet(ctx, "missing", fn); rst.Err() != cache.Nil {
+			t.Fatalf("keyring: expected cache.Nil, got %q, %v", rst.Val(), rst.Err())
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("keyring: expected the nil value to be cached, loader called %d times", calls)
+	}
+
+	if rst := store.Get(ctx, "missing"); rst.Err() != cache.Nil {
+		t.Fatalf("keyring: expected cache.Nil, got %q, %v", rst.Val(), rst.Err())
+	}
+
+	if rst, _ := store.GetMany(ctx, "missing"); rst["missing"].Err() != cache.Nil {
+		t.Fatalf("keyring: expected cache.Nil, got %q, %v", rst["missing"].Val(), rst["missing"].Err())
+	}
+}
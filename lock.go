@@ -7,16 +7,368 @@
 
 package cache
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	lockMinBackoff = 10 * time.Millisecond
+	lockMaxBackoff = 500 * time.Millisecond
+	lockWatchRatio = 3
+	lockForever    = 1<<53 - 1
+)
 
 type Lock interface {
 	// Acquire Attempt to acquire the lock.
-	Acquire() (bool, error)
-	// Release Release the lock.
-	Release() error
+	Acquire(ctx context.Context) (bool, error)
+	// Release Release the lock if it is still owned by the current owner.
+	Release(ctx context.Context) (bool, error)
+	// ForceRelease Release the lock regardless of ownership.
+	ForceRelease(ctx context.Context) error
+	// Owner Return the owner token of the lock.
+	Owner() string
+	// Token Return the fencing token issued when the lock was last acquired, zero if never.
+	// The tokens restart from 1 if the store evicts the counter, see Cache.FencingToken.
+	Token() int64
+	// Get Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+	Get(ctx context.Context, callbacks ...func() error) (bool, error)
+	// Block Attempt to acquire the lock until the wait runs out or the context ends,
+	// if acquired, run the callbacks and then release the lock.
+	Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error
+	// Extend Refresh the lease of the lock to the given ttl if it is still owned by the current owner,
+	// a non-positive ttl means the time of the lock, ErrInvalidTTL is returned if the lock has no time.
+	Extend(ctx context.Context, ttl time.Duration) (bool, error)
+	// Watch Renew the lease of the lock in the background every third of its time until the lock is released
+	// or the context ends. The returned channel receives an error when the lock is lost, and is closed when
+	// the watchdog stops.
+	Watch(ctx context.Context) <-chan error
+}
+
+type (
+	BaseLock struct {
+		name    string
+		time    time.Duration
+		owner   string
+		token   int64
+		watcher *lockWatcher
+	}
+
+	lockWatcher struct {
+		mu     sync.Mutex
+		cancel context.CancelFunc
+	}
+
+	// updateFunc Atomically update the value of a prefixed key. The fn receives the current value, nil if missing,
+	// and returns the new value with its expiration, a nil value removes the key, errNoUpdate leaves it untouched.
+	updateFunc func(ctx context.Context, key string, fn func(value []byte) ([]byte, time.Duration, error)) error
+
+	// lockState The holders of a shared lock stored as a single value, used by the stores without scripting.
+	lockState struct {
+		Writer         string           `json:"w,omitempty"`
+		WriterExpireAt int64            `json:"we,omitempty"`
+		Holders        map[string]int64 `json:"h,omitempty"`
+	}
+)
+
+// newBaseLock Create a base lock, a random owner token is generated if the owner is not given.
+func newBaseLock(name string, time time.Duration, owner ...string) BaseLock {
+	l := BaseLock{
+		name:    name,
+		time:    time,
+		watcher: &lockWatcher{},
+	}
+
+	if len(owner) > 0 && owner[0] != "" {
+		l.owner = owner[0]
+	} else {
+		l.owner = randomToken()
+	}
+
+	return l
+}
+
+// Owner Return the owner token of the lock.
+func (l *BaseLock) Owner() string {
+	return l.owner
+}
+
+// Token Return the fencing token issued when the lock was last acquired, zero if never.
+func (l *BaseLock) Token() int64 {
+	return atomic.LoadInt64(&l.token)
+}
+
+// setToken Keep the fencing token issued by the acquisition.
+func (l *BaseLock) setToken(token int64) {
+	atomic.StoreInt64(&l.token, token)
 }
 
-type BaseLock struct {
-	name string
-	time time.Duration
+// watch Start a watchdog renewing the lease of the lock with the extend function.
+// Failed renewals are retried until the lease would have expired.
+func (l *BaseLock) watch(ctx context.Context, extend func(ctx context.Context, ttl time.Duration) (bool, error)) <-chan error {
+	lost := make(chan error, 1)
+
+	if l.time <= 0 {
+		close(lost)
+		return lost
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	l.watcher.mu.Lock()
+	if l.watcher.cancel != nil {
+		l.watcher.cancel()
+	}
+	l.watcher.cancel = cancel
+	l.watcher.mu.Unlock()
+
+	go func() {
+		defer close(lost)
+		defer cancel()
+
+		var (
+			ticker  = time.NewTicker(l.time / lockWatchRatio)
+			renewed = time.Now()
+		)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			ok, err := extend(ctx, l.time)
+			if ctx.Err() != nil {
+				return
+			}
+
+			switch {
+			case err == nil && ok:
+				renewed = time.Now()
+			case err == nil:
+				lost <- ErrLockLost
+				return
+			case time.Since(renewed) >= l.time:
+				lost <- err
+				return
+			}
+		}
+	}()
+
+	return lost
+}
+
+// unwatch Stop the watchdog of the lock if it is running.
+func (l *BaseLock) unwatch() {
+	l.watcher.mu.Lock()
+	if l.watcher.cancel != nil {
+		l.watcher.cancel()
+		l.watcher.cancel = nil
+	}
+	l.watcher.mu.Unlock()
+}
+
+// extendTTL Return the ttl used to extend the lease of the lock.
+// A restored lock has no time of its own, so it must be extended with an explicit ttl.
+func (l *BaseLock) extendTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl <= 0 {
+		ttl = l.time
+	}
+
+	if ttl <= 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	return ttl, nil
+}
+
+// getLock Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+func getLock(ctx context.Context, l Lock, callbacks []func() error) (bool, error) {
+	ok, err := l.Acquire(ctx)
+	if err != nil || !ok || len(callbacks) == 0 {
+		return ok, err
+	}
+
+	return true, runLocked(ctx, l.Release, callbacks)
+}
+
+// blockLock Attempt to acquire the lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the lock.
+func blockLock(ctx context.Context, l Lock, name string, wait time.Duration, callbacks []func() error) error {
+	return blockRun(ctx, name, wait, l.Acquire, l.Release, callbacks)
+}
+
+// blockRun Attempt to acquire until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release.
+func blockRun(ctx context.Context, name string, wait time.Duration, acquire, release func(ctx context.Context) (bool, error), callbacks []func() error) error {
+	if err := blockAcquire(ctx, name, wait, acquire); err != nil {
+		return err
+	}
+
+	if len(callbacks) == 0 {
+		return nil
+	}
+
+	return runLocked(ctx, release, callbacks)
+}
+
+// blockAcquire Attempt to acquire with jittered exponential backoff until the wait runs out or the context ends.
+func blockAcquire(ctx context.Context, name string, wait time.Duration, acquire func(ctx context.Context) (bool, error)) error {
+	var (
+		deadline = time.Now().Add(wait)
+		backoff  = lockMinBackoff
+		timer    *time.Timer
+	)
+
+	for {
+		ok, err := acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return &LockTimeoutError{Name: name, Wait: wait}
+		}
+
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if sleep > remaining {
+			sleep = remaining
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(sleep)
+			defer timer.Stop()
+		} else {
+			timer.Reset(sleep)
+		}
+
+		select {
+		case <-ctx.Done():
+			return &LockTimeoutError{Name: name, Wait: wait, Err: ctx.Err()}
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > lockMaxBackoff {
+			backoff = lockMaxBackoff
+		}
+	}
+}
+
+// runLocked Run the callbacks while holding the lock, the lock is always released even if a callback panics.
+// The release isn't canceled along with the context, so that the lock is not left held until it expires.
+func runLocked(ctx context.Context, release func(ctx context.Context) (bool, error), callbacks []func() error) (err error) {
+	defer func() {
+		if _, e := release(context.WithoutCancel(ctx)); err == nil {
+			err = e
+		}
+	}()
+
+	for _, fn := range callbacks {
+		if err = fn(); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// decodeLockState Decode the lock state from a value, a nil value means no holder.
+func decodeLockState(value []byte) (*lockState, error) {
+	st := &lockState{}
+
+	if value != nil {
+		if err := json.Unmarshal(value, st); err != nil {
+			return nil, err
+		}
+	}
+
+	if st.Holders == nil {
+		st.Holders = make(map[string]int64)
+	}
+
+	return st, nil
+}
+
+// encode Encode the lock state with the expiration of its last holder, a nil value means no holder.
+func (st *lockState) encode(now time.Time) ([]byte, time.Duration, error) {
+	var (
+		nowMs    = nowMs(now)
+		expireAt = st.WriterExpireAt
+		forever  = st.Writer != "" && st.WriterExpireAt == 0
+	)
+
+	if st.Writer == "" && len(st.Holders) == 0 {
+		return nil, 0, nil
+	}
+
+	for _, at := range st.Holders {
+		if at == 0 {
+			forever = true
+		} else if at > expireAt {
+			expireAt = at
+		}
+	}
+
+	value, err := json.Marshal(st)
+	if err != nil || forever {
+		return value, 0, err
+	}
+
+	return value, time.Duration(expireAt-nowMs) * time.Millisecond, nil
+}
+
+// prune Remove the expired holders.
+func (st *lockState) prune(now time.Time) {
+	nowMs := nowMs(now)
+
+	if st.Writer != "" && st.WriterExpireAt != 0 && st.WriterExpireAt <= nowMs {
+		st.Writer, st.WriterExpireAt = "", 0
+	}
+
+	for owner, at := range st.Holders {
+		if at != 0 && at <= nowMs {
+			delete(st.Holders, owner)
+		}
+	}
+}
+
+// lockExpireAt Return the expiration timestamp in milliseconds of a holder, zero means never.
+func lockExpireAt(now time.Time, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return nowMs(now.Add(ttl))
+}
+
+// nowMs Return the timestamp in milliseconds.
+func nowMs(now time.Time) int64 {
+	return now.UnixNano() / int64(time.Millisecond)
+}
+
+// fencingKey Get the key of the fencing token counter of a lock, which shares the hash slot with the lock.
+func fencingKey(name string) string {
+	return fmt.Sprintf("{%s}:fencing", name)
+}
+
+// checkFencingToken Determine if a fencing token is not older than the latest one issued for a lock.
+func checkFencingToken(ctx context.Context, store Store, name string, token int64) (bool, error) {
+	latest, err := store.FencingToken(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	return token > 0 && token >= latest, nil
 }
@@ -0,0 +1,521 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 6:20 下午
+ * @Desc: lock test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestLock_Get(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		lock  = store.Lock("job", time.Minute)
+		ran   bool
+	)
+
+	ok, err := lock.Get(ctx, func() error {
+		if held, _ := store.Lock("job", time.Minute).Acquire(ctx); held {
+			t.Fatal("lock: expected the lock to be held while running the callback")
+		}
+		ran = true
+		return nil
+	})
+	if err != nil || !ok || !ran {
+		t.Fatalf("lock: expected the callback to run, got %v, %v", ok, err)
+	}
+
+	if held, _ := store.Lock("job", time.Minute).Acquire(ctx); !held {
+		t.Fatal("lock: expected the lock to be released after the callback")
+	}
+}
+
+func TestLock_Block(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, store := range map[string]cache.Store{
+		"memory":    newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}),
+		"memcached": newMemcachedStore(t, nil),
+	} {
+		lock := store.Lock("job", time.Minute)
+
+		if ok, _ := lock.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the lock", driver)
+		}
+
+		err := store.Lock("job", time.Minute).Block(ctx, 50*time.Millisecond)
+
+		var timeoutErr *cache.LockTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("%s: expected a lock timeout error, got %v", driver, err)
+		}
+
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			_, _ = lock.Release(ctx)
+		}()
+
+		errFailed := errors.New("failed")
+		if err = store.Lock("job", time.Minute).Block(ctx, time.Second, func() error {
+			return errFailed
+		}); err != errFailed {
+			t.Fatalf("%s: expected the callback error, got %v", driver, err)
+		}
+
+		if ok, _ := lock.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected the lock to be released after the callback", driver)
+		}
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if err = store.Lock("job", time.Minute).Block(canceled, time.Second); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected the context error, got %v", driver, err)
+		}
+	}
+}
+
+func TestMemcachedLock(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemcachedStore(t, nil)
+		lock  = store.Lock("job", time.Minute)
+	)
+
+	if ok, err := lock.Acquire(ctx); err != nil || !ok {
+		t.Fatalf("mc: expected to acquire the lock, got %v, %v", ok, err)
+	}
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("mc: expected the lock to be held")
+	}
+
+	if ok, _ := store.Lock("job", time.Minute).Release(ctx); ok {
+		t.Fatal("mc: expected the lock not to be released by another owner")
+	}
+
+	if ok, err := store.RestoreLock("job", lock.Owner()).Release(ctx); err != nil || !ok {
+		t.Fatalf("mc: expected the restored lock to be released, got %v, %v", ok, err)
+	}
+
+	if ok, _ := lock.Release(ctx); ok {
+		t.Fatal("mc: expected the released lock not to be released again")
+	}
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("mc: expected to acquire the released lock")
+	}
+
+	if err := store.RestoreLock("job", "").ForceRelease(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); !ok {
+		t.Fatal("mc: expected to acquire the force released lock")
+	}
+
+	// a sub-second lease rounds up to a second instead of never expiring
+	if ok, _ := store.Lock("short", 100*time.Millisecond).Acquire(ctx); !ok {
+		t.Fatal("mc: expected to acquire the lock")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if ok, _ := store.Lock("short", time.Minute).Acquire(ctx); !ok {
+		t.Fatal("mc: expected the sub-second lease to expire")
+	}
+}
+
+func TestLock_Extend(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		lock  = store.Lock("job", 20*time.Millisecond)
+	)
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("lock: expected to acquire the lock")
+	}
+
+	if ok, _ := store.Lock("job", time.Minute).Extend(ctx, time.Minute); ok {
+		t.Fatal("lock: expected the lock not to be extended by another owner")
+	}
+
+	if ok, _ := lock.Extend(ctx, time.Minute); !ok {
+		t.Fatal("lock: expected the lock to be extended")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("lock: expected the extended lock to be held")
+	}
+}
+
+func TestLock_ExtendRestored(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, store := range map[string]cache.Store{
+		"memory":    newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}),
+		"redis":     newRedisStore(t, &cache.RedisOptions{}),
+		"memcached": newMemcachedStore(t, nil),
+	} {
+		lock := store.Lock("job", time.Minute)
+
+		if ok, _ := lock.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the lock", driver)
+		}
+
+		restored := store.RestoreLock("job", lock.Owner())
+
+		if ok, err := restored.Extend(ctx, 0); ok || err != cache.ErrInvalidTTL {
+			t.Fatalf("%s: expected cache.ErrInvalidTTL, got %v, %v", driver, ok, err)
+		}
+
+		if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+			t.Fatalf("%s: expected the lock to survive the rejected extension", driver)
+		}
+
+		if ok, err := restored.Extend(ctx, time.Minute); err != nil || !ok {
+			t.Fatalf("%s: expected the restored lock to be extended with a ttl, got %v, %v", driver, ok, err)
+		}
+	}
+}
+
+func TestMemcachedLock_Extend(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemcachedStore(t, nil)
+		lock  = store.Lock("job", 100*time.Millisecond)
+	)
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("mc: expected to acquire the lock")
+	}
+
+	// the lease rounds up to two seconds instead of being truncated to one
+	if ok, err := lock.Extend(ctx, 1500*time.Millisecond); err != nil || !ok {
+		t.Fatalf("mc: expected the lock to be extended, got %v, %v", ok, err)
+	}
+
+	time.Sleep(1200 * time.Millisecond)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("mc: expected the extended lock to be held")
+	}
+
+	// a sub-second lease rounds up to a second instead of never expiring
+	if ok, _ := lock.Extend(ctx, 200*time.Millisecond); !ok {
+		t.Fatal("mc: expected the lock to be extended")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); !ok {
+		t.Fatal("mc: expected the sub-second lease to expire")
+	}
+}
+
+func TestMemcachedLock_Watch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemcachedStore(t, nil)
+		lock  = store.Lock("job", time.Second)
+	)
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("mc: expected to acquire the lock")
+	}
+
+	lost := lock.Watch(ctx)
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("mc: expected the watched lock to be held")
+	}
+
+	_ = store.RestoreLock("job", "").ForceRelease(ctx)
+
+	select {
+	case err := <-lost:
+		if err != cache.ErrLockLost {
+			t.Fatalf("mc: expected cache.ErrLockLost, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("mc: expected the lost lock to be reported")
+	}
+}
+
+func TestLock_Watch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		lock  = store.Lock("job", 30*time.Millisecond)
+	)
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("lock: expected to acquire the lock")
+	}
+
+	lost := lock.Watch(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("lock: expected the watched lock to be held")
+	}
+
+	_ = store.RestoreLock("job", "").ForceRelease(ctx)
+
+	select {
+	case err := <-lost:
+		if err != cache.ErrLockLost {
+			t.Fatalf("lock: expected cache.ErrLockLost, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock: expected the lost lock to be reported")
+	}
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("lock: expected to acquire the lock")
+	}
+
+	lost = lock.Watch(ctx)
+
+	if ok, _ := lock.Release(ctx); !ok {
+		t.Fatal("lock: expected the lock to be released")
+	}
+
+	if err, ok := <-lost; ok {
+		t.Fatalf("lock: expected the watchdog to stop silently, got %v", err)
+	}
+}
+
+func TestSemaphore(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		_, clients = newRedisNodes(t, 1)
+		store      = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		mc         = newMemcachedStore(t, nil)
+	)
+
+	for driver, newSemaphore := range map[string]func(ttl time.Duration) cache.Semaphore{
+		"memory":    func(ttl time.Duration) cache.Semaphore { return store.Semaphore("api", 2, ttl) },
+		"redis":     func(ttl time.Duration) cache.Semaphore { return cache.NewRedisSemaphore(clients[0], "api", 2, ttl) },
+		"memcached": func(ttl time.Duration) cache.Semaphore { return mc.Semaphore("api", 2, ttl) },
+	} {
+		var (
+			a = newSemaphore(time.Minute)
+			b = newSemaphore(time.Minute)
+			c = newSemaphore(50 * time.Millisecond)
+		)
+
+		if ok, err := a.Acquire(ctx); err != nil || !ok {
+			t.Fatalf("%s: expected to acquire a permit, got %v, %v", driver, ok, err)
+		}
+
+		if ok, _ := b.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected to acquire a permit", driver)
+		}
+
+		if ok, _ := c.Acquire(ctx); ok {
+			t.Fatalf("%s: expected no permit left", driver)
+		}
+
+		if n, _ := a.Count(ctx); n != 2 {
+			t.Fatalf("%s: expected two permits in use, got %d", driver, n)
+		}
+
+		if ok, _ := b.Release(ctx); !ok {
+			t.Fatalf("%s: expected the permit to be released", driver)
+		}
+
+		if ok, _ := c.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the released permit", driver)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+
+		if ok, _ := b.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected the stale permit to expire", driver)
+		}
+
+		_, _ = a.Release(ctx)
+		_, _ = b.Release(ctx)
+	}
+}
+
+func TestRWLock(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		_, clients = newRedisNodes(t, 1)
+		store      = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		mc         = newMemcachedStore(t, nil)
+	)
+
+	for driver, newRWLock := range map[string]func() cache.RWLock{
+		"memory":    func() cache.RWLock { return store.RWLock("doc", time.Minute) },
+		"redis":     func() cache.RWLock { return cache.NewRedisRWLock(clients[0], "doc", time.Minute) },
+		"memcached": func() cache.RWLock { return mc.RWLock("doc", time.Minute) },
+	} {
+		var (
+			reader1 = newRWLock()
+			reader2 = newRWLock()
+			writer  = newRWLock()
+		)
+
+		if ok, err := reader1.RLock(ctx); err != nil || !ok {
+			t.Fatalf("%s: expected to acquire the read lock, got %v, %v", driver, ok, err)
+		}
+
+		if ok, _ := reader2.RLock(ctx); !ok {
+			t.Fatalf("%s: expected the read lock to be shared", driver)
+		}
+
+		if ok, _ := writer.Lock(ctx); ok {
+			t.Fatalf("%s: expected the write lock to wait for readers", driver)
+		}
+
+		_, _ = reader1.RUnlock(ctx)
+		_, _ = reader2.RUnlock(ctx)
+
+		if ok, _ := writer.Lock(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the write lock", driver)
+		}
+
+		if ok, _ := reader1.RLock(ctx); ok {
+			t.Fatalf("%s: expected the read lock to wait for the writer", driver)
+		}
+
+		if ok, _ := reader1.Unlock(ctx); ok {
+			t.Fatalf("%s: expected the write lock not to be released by another owner", driver)
+		}
+
+		if ok, _ := writer.Unlock(ctx); !ok {
+			t.Fatalf("%s: expected the write lock to be released", driver)
+		}
+
+		if ok, _ := reader1.RLock(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the read lock", driver)
+		}
+
+		_, _ = reader1.RUnlock(ctx)
+	}
+}
+
+func TestLock_SharedName(t *testing.T) {
+	ctx := context.Background()
+
+	for driver, store := range map[string]cache.Store{
+		"memory":    newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}),
+		"redis":     newRedisStore(t, &cache.RedisOptions{}),
+		"memcached": newMemcachedStore(t, nil),
+	} {
+		lock := store.Lock("job", time.Minute)
+
+		if ok, _ := lock.Acquire(ctx); !ok {
+			t.Fatalf("%s: expected to acquire the lock", driver)
+		}
+
+		if ok, err := store.RWLock("job", time.Minute).Lock(ctx); err != nil || !ok {
+			t.Fatalf("%s: expected the read-write lock not to collide with the lock, got %v, %v", driver, ok, err)
+		}
+
+		if ok, err := store.Semaphore("job", 1, time.Minute).Acquire(ctx); err != nil || !ok {
+			t.Fatalf("%s: expected the semaphore not to collide with the lock, got %v, %v", driver, ok, err)
+		}
+
+		if ok, err := lock.Release(ctx); err != nil || !ok {
+			t.Fatalf("%s: expected the lock to be released, got %v, %v", driver, ok, err)
+		}
+	}
+}
+
+func TestRedisLock_ServerClock(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		servers, clients = newRedisNodes(t, 1)
+		now              = time.Now().Add(-time.Hour)
+	)
+
+	// the holders expire on the clock of the server, whatever the clock of the client
+	servers[0].SetTime(now)
+
+	if ok, _ := cache.NewRedisSemaphore(clients[0], "api", 1, time.Minute).Acquire(ctx); !ok {
+		t.Fatal("redis: expected to acquire a permit")
+	}
+
+	if ok, _ := cache.NewRedisRWLock(clients[0], "doc", time.Minute).RLock(ctx); !ok {
+		t.Fatal("redis: expected to acquire the read lock")
+	}
+
+	if ok, _ := cache.NewRedisSemaphore(clients[0], "api", 1, time.Minute).Acquire(ctx); ok {
+		t.Fatal("redis: expected no permit left")
+	}
+
+	if ok, _ := cache.NewRedisRWLock(clients[0], "doc", time.Minute).Lock(ctx); ok {
+		t.Fatal("redis: expected the write lock to wait for the reader")
+	}
+
+	servers[0].SetTime(now.Add(2 * time.Minute))
+
+	if ok, _ := cache.NewRedisSemaphore(clients[0], "api", 1, time.Minute).Acquire(ctx); !ok {
+		t.Fatal("redis: expected the permit to expire on the clock of the server")
+	}
+
+	if ok, _ := cache.NewRedisRWLock(clients[0], "doc", time.Minute).Lock(ctx); !ok {
+		t.Fatal("redis: expected the read lock to expire on the clock of the server")
+	}
+}
+
+func TestLock_Token(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		_, clients = newRedisNodes(t, 1)
+		store      = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		c          = cache.NewCacheWithStore(store)
+	)
+
+	for driver, newLock := range map[string]func() cache.Lock{
+		"memory": func() cache.Lock { return store.Lock("job", time.Minute) },
+		"redis":  func() cache.Lock { return cache.NewRedisLock(clients[0], "job", time.Minute) },
+	} {
+		var (
+			first  = newLock()
+			second = newLock()
+		)
+
+		if ok, _ := first.Acquire(ctx); !ok || first.Token() != 1 {
+			t.Fatalf("%s: expected the first token, got %d", driver, first.Token())
+		}
+
+		if ok, _ := second.Acquire(ctx); ok || second.Token() != 0 {
+			t.Fatalf("%s: expected no token for a failed acquisition, got %d", driver, second.Token())
+		}
+
+		_, _ = first.Release(ctx)
+
+		if ok, _ := second.Acquire(ctx); !ok || second.Token() != 2 {
+			t.Fatalf("%s: expected the token to increase, got %d", driver, second.Token())
+		}
+	}
+
+	if ok, _ := c.CheckFencingToken(ctx, "job", 1); ok {
+		t.Fatal("lock: expected the stale token to be rejected")
+	}
+
+	if ok, err := c.CheckFencingToken(ctx, "job", 2); err != nil || !ok {
+		t.Fatalf("lock: expected the latest token to be accepted, got %v, %v", ok, err)
+	}
+}
@@ -8,49 +8,239 @@
 package cache
 
 import (
+	"context"
 	"time"
-	
+
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
+const memcachedMaxCASRetries = 16
+
 type MemcachedLock struct {
 	BaseLock
 	client *Memcached
 }
 
 // NewMemcachedLock Create a memcached lock instance.
-func NewMemcachedLock(client *Memcached, name string, time time.Duration) Lock {
+func NewMemcachedLock(client *Memcached, name string, time time.Duration, owner ...string) Lock {
 	return &MemcachedLock{
-		BaseLock: BaseLock{
-			name: name,
-			time: time,
-		},
-		client: client,
+		BaseLock: newBaseLock(name, time, owner...),
+		client:   client,
 	}
 }
 
 // Acquire Attempt to acquire the lock.
-func (l *MemcachedLock) Acquire() (bool, error) {
-	if err := l.client.Add(&memcache.Item{
+// The fencing token is issued before the acquisition and burned if it fails, so that a holder never gets
+// a token lower than any token issued before it acquired the lock.
+func (l *MemcachedLock) Acquire(ctx context.Context) (bool, error) {
+	conn := memcachedConn{ctx: ctx, client: l.client}
+
+	token, err := memcachedIncrement(conn, fencingKey(l.name))
+	if err != nil {
+		return false, err
+	}
+
+	if err = conn.Add(&memcache.Item{
 		Key:        l.name,
-		Value:      []byte("1"),
-		Expiration: int32(l.time / time.Second),
+		Value:      []byte(l.owner),
+		Expiration: memcachedExpiration(l.time),
 	}); err != nil {
 		if err == memcache.ErrNotStored {
 			return false, nil
 		}
-		
+
 		return false, err
-	} else {
-		return true, nil
 	}
+
+	l.setToken(token)
+
+	return true, nil
 }
 
-// Release Release the lock.
-func (l *MemcachedLock) Release() error {
-	if err := l.client.Delete(l.name); err != nil && err != memcache.ErrCacheMiss {
+// Release Release the lock if it is still owned by the current owner.
+// The lock item is expired by a cas operation, so that it is never removed after being acquired by another owner.
+func (l *MemcachedLock) Release(ctx context.Context) (bool, error) {
+	l.unwatch()
+
+	conn := memcachedConn{ctx: ctx, client: l.client}
+
+	item, err := conn.Get(l.name)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if string(item.Value) != l.owner {
+		return false, nil
+	}
+
+	item.Expiration = -1
+
+	if err = conn.CompareAndSwap(item); err != nil {
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored || err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ForceRelease Release the lock regardless of ownership.
+func (l *MemcachedLock) ForceRelease(ctx context.Context) error {
+	l.unwatch()
+
+	if err := (memcachedConn{ctx: ctx, client: l.client}).Delete(l.name); err != nil && err != memcache.ErrCacheMiss {
 		return err
 	}
-	
+
 	return nil
 }
+
+// Get Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+func (l *MemcachedLock) Get(ctx context.Context, callbacks ...func() error) (bool, error) {
+	return getLock(ctx, l, callbacks)
+}
+
+// Block Attempt to acquire the lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the lock.
+func (l *MemcachedLock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockLock(ctx, l, l.name, wait, callbacks)
+}
+
+// Extend Refresh the lease of the lock to the given ttl if it is still owned by the current owner.
+func (l *MemcachedLock) Extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	ttl, err := l.extendTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+
+	conn := memcachedConn{ctx: ctx, client: l.client}
+
+	item, err := conn.Get(l.name)
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if string(item.Value) != l.owner {
+		return false, nil
+	}
+
+	item.Expiration = memcachedExpiration(ttl)
+
+	if err = conn.CompareAndSwap(item); err != nil {
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored || err == memcache.ErrCacheMiss {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Watch Renew the lease of the lock in the background until the lock is released or the context ends.
+func (l *MemcachedLock) Watch(ctx context.Context) <-chan error {
+	return l.watch(ctx, l.Extend)
+}
+
+// NewMemcachedRWLock Create a memcached read-write lock instance.
+func NewMemcachedRWLock(client *Memcached, name string, time time.Duration, owner ...string) RWLock {
+	return newCASRWLock(memcachedUpdate(client), name, time, owner...)
+}
+
+// NewMemcachedSemaphore Create a memcached semaphore instance.
+func NewMemcachedSemaphore(client *Memcached, name string, permits int, time time.Duration, owner ...string) Semaphore {
+	return newCASSemaphore(memcachedUpdate(client), name, permits, time, owner...)
+}
+
+// memcachedUpdate Return an update function retrying the gets and cas round trip on conflict.
+func memcachedUpdate(client *Memcached) updateFunc {
+	return func(ctx context.Context, key string, fn func(value []byte) ([]byte, time.Duration, error)) error {
+		conn := memcachedConn{ctx: ctx, client: client}
+
+		for i := 0; i < memcachedMaxCASRetries; i++ {
+			item, err := conn.Get(key)
+			if err != nil && err != memcache.ErrCacheMiss {
+				return err
+			}
+
+			var current []byte
+			if item != nil {
+				current = item.Value
+			}
+
+			value, expire, err := fn(current)
+			if err != nil {
+				if err == errNoUpdate {
+					return nil
+				}
+
+				return err
+			}
+
+			switch {
+			case item == nil && value == nil:
+				return nil
+			case item == nil:
+				err = conn.Add(&memcache.Item{Key: key, Value: value, Expiration: memcachedExpiration(expire)})
+			case value == nil:
+				item.Expiration = -1
+				err = conn.CompareAndSwap(item)
+			default:
+				item.Value, item.Expiration = value, memcachedExpiration(expire)
+				err = conn.CompareAndSwap(item)
+			}
+
+			switch err {
+			case nil:
+				return nil
+			case memcache.ErrNotStored, memcache.ErrCASConflict, memcache.ErrCacheMiss:
+				continue
+			default:
+				return err
+			}
+		}
+
+		return ErrCASConflict
+	}
+}
+
+// memcachedIncrement Increment a counter by one, initializing it if missing.
+func memcachedIncrement(conn memcachedConn, key string) (int64, error) {
+	for i := 0; i < memcachedMaxCASRetries; i++ {
+		val, err := conn.Increment(key, 1)
+		if err == nil {
+			return int64(val), nil
+		}
+
+		if err != memcache.ErrCacheMiss {
+			return 0, err
+		}
+
+		if err = conn.Add(&memcache.Item{Key: key, Value: []byte("1")}); err == nil {
+			return 1, nil
+		} else if err != memcache.ErrNotStored {
+			return 0, err
+		}
+	}
+
+	return 0, ErrCASConflict
+}
+
+// memcachedExpiration Convert the expire to memcached expiration seconds, rounded up so that it never expires early.
+func memcachedExpiration(expire time.Duration) int32 {
+	if expire <= 0 {
+		return 0
+	}
+
+	return int32((expire + time.Second - 1) / time.Second)
+}
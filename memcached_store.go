@@ -9,6 +9,8 @@ package cache
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -20,31 +22,59 @@ type (
 	Memcached      = memcache.Client
 	MemcachedStore struct {
 		BaseStore
-		client *Memcached
+		client          *Memcached
+		batcher         *getBatcher
+		pipelineWorkers int
 	}
 	MemcachedOptions struct {
 		Addrs            []string
 		Prefix           string
 		DefaultNilValue  string
 		DefaultNilExpire int64
+		Codec            Codec
+		// Compressor The compressor compressing the values, the values are stored uncompressed if it's nil.
+		Compressor Compressor
+		// CompressThreshold The values shorter than the threshold in bytes are stored uncompressed, default 1024.
+		CompressThreshold int
+		// Keyring The keyring sealing the values, the values are stored in plain if it's nil.
+		// The values sealed with a rotated key are not sealed again when read, since memcached
+		// can't report the expiration to keep.
+		Keyring *Keyring
+		// BatchWindow The window merging the concurrent Get calls into a single GetMulti, zero disables the batching.
+		BatchWindow time.Duration
+		// BatchSize The number of keys flushing a batch before its window ends, default 100.
+		BatchSize int
+		// PipelineWorkers The number of workers running the operations of a pipeline at once, default 8.
+		// The operations on the same key are run in the queued order.
+		PipelineWorkers int
 	}
 )
 
 // NewMemcachedStore Create a memcached store instance.
+// The memcached client takes no context, so a call returns ctx.Err() as soon as its context ends
+// while the request keeps running, a cancelled Set, Add, Increment or Forget may still be applied afterwards.
 func NewMemcachedStore(opt *MemcachedOptions) Store {
 	c := &MemcachedStore{
-		client: memcache.New(opt.Addrs...),
+		client:          memcache.New(opt.Addrs...),
+		pipelineWorkers: opt.PipelineWorkers,
 	}
 	c.SetPrefix(opt.Prefix)
 	c.SetDefaultNilValue(opt.DefaultNilValue)
 	c.SetDefaultNilExpire(opt.DefaultNilExpire)
+	c.SetCodec(opt.Codec)
+	c.SetCompressor(opt.Compressor, opt.CompressThreshold)
+	c.SetKeyring(opt.Keyring)
+
+	if opt.BatchWindow > 0 {
+		c.batcher = newGetBatcher(opt.BatchWindow, opt.BatchSize, c.GetMany)
+	}
 
 	return c
 }
 
 // Has Determine if an item exists in the cache.
 func (c *MemcachedStore) Has(ctx context.Context, key string) (bool, error) {
-	if _, err := c.client.Get(c.PrefixKey(key)); err != nil {
+	if _, err := c.conn(ctx).Get(c.PrefixKey(key)); err != nil {
 		if err == memcache.ErrCacheMiss {
 			return false, nil
 		}
@@ -68,18 +98,19 @@ func (c *MemcachedStore) HasMany(ctx context.Context, keys ...string) (map[strin
 		}
 	}
 
-	for i, v := range keys {
-		keys[i] = c.PrefixKey(v)
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = c.PrefixKey(key)
 	}
 
-	items, err := c.client.GetMulti(keys)
+	items, err := c.conn(ctx).GetMulti(prefixedKeys)
 	if err != nil {
 		return nil, err
 	}
 
 	ret := make(map[string]bool)
-	for _, key := range keys {
-		if _, ok := items[key]; ok {
+	for i, key := range keys {
+		if _, ok := items[prefixedKeys[i]]; ok {
 			ret[key] = true
 		} else {
 			ret[key] = false
@@ -89,9 +120,13 @@ func (c *MemcachedStore) HasMany(ctx context.Context, keys ...string) (map[strin
 	return ret, err
 }
 
-// Get Retrieve an item from the cache by key.
+// Get Retrieve an item from the cache by key, the concurrent calls are merged into a single GetMulti if the batching is enabled.
 func (c *MemcachedStore) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
-	item, err := c.client.Get(c.PrefixKey(key))
+	if c.batcher != nil {
+		return c.batcher.get(ctx, key, defaultValue...)
+	}
+
+	item, err := c.conn(ctx).Get(c.PrefixKey(key))
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
 			if len(defaultValue) > 0 {
@@ -104,7 +139,7 @@ func (c *MemcachedStore) Get(ctx context.Context, key string, defaultValue ...in
 		}
 	}
 
-	return NewResult(string(item.Value))
+	return newStoredResult(c.decode(string(item.Value)))
 }
 
 // GetMany Retrieve multiple items from the cache by key.
@@ -115,17 +150,17 @@ func (c *MemcachedStore) GetMany(ctx context.Context, keys ...string) (map[strin
 	)
 
 	for _, key := range keys {
-		prefixedKeys = append(prefixedKeys, key)
+		prefixedKeys = append(prefixedKeys, c.PrefixKey(key))
 	}
 
-	items, err := c.client.GetMulti(prefixedKeys)
+	items, err := c.conn(ctx).GetMulti(prefixedKeys)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, key := range keys {
 		if item, ok := items[c.PrefixKey(key)]; ok {
-			ret[key] = NewResult(string(item.Value), nil)
+			ret[key] = newStoredResult(c.decode(string(item.Value)))
 		} else {
 			ret[key] = NewResult("", Nil)
 		}
@@ -134,13 +169,39 @@ func (c *MemcachedStore) GetMany(ctx context.Context, keys ...string) (map[strin
 	return ret, nil
 }
 
+// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn.
+func (c *MemcachedStore) GetManySet(ctx context.Context, keys []string, fn defaultValuesFunc) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = c.PrefixKey(key)
+	}
+
+	items, err := c.conn(ctx).GetMulti(prefixedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	raws := make(map[string]string, len(items))
+	for i, key := range keys {
+		if item, ok := items[prefixedKeys[i]]; ok {
+			raws[key] = string(item.Value)
+		}
+	}
+
+	return c.getManySet(ctx, c, keys, raws, fn)
+}
+
 // GetSet Retrieve or set an item from the cache by key.
-func (c *MemcachedStore) GetSet(key string, fn defaultValueFunc) Result {
-	if item, err := c.client.Get(c.PrefixKey(key)); err != nil {
+func (c *MemcachedStore) GetSet(ctx context.Context, key string, fn defaultValueFunc) Result {
+	if item, err := c.conn(ctx).Get(c.PrefixKey(key)); err != nil {
 		if err != memcache.ErrCacheMiss {
 			return NewResult("", err)
 		} else {
-			switch ret, err := storeSharedCallGroup.Call(key, func() (interface{}, error) {
+			switch ret, err := c.shareCall(ctx, key, func() (interface{}, error) {
 				val, expire, err := fn()
 				return defaultValueRet{
 					val:    val,
@@ -149,41 +210,93 @@ func (c *MemcachedStore) GetSet(key string, fn defaultValueFunc) Result {
 			}); err {
 			case nil:
 				ret := ret.(defaultValueRet)
-				val := conv.String(ret.val)
-				return NewResult(val, nil, c.Set(key, val, ret.expire))
+				val, err := encodeValue(c.GetCodec(), ret.val)
+				if err != nil {
+					return NewResult("", err)
+				}
+				return newStoredResult(val, nil, c.Set(ctx, key, encodedValue(val), ret.expire))
 			case Nil:
 				ret := ret.(defaultValueRet)
 				expire := c.GetDefaultNilExpire()
 				if ret.expire > 0 {
 					expire = ret.expire
 				}
-				return NewResult("", Nil, c.Set(key, c.GetDefaultNilValue(), expire))
+				return NewResult("", Nil, c.Set(ctx, key, c.GetDefaultNilValue(), expire))
 			default:
 				return NewResult("", err)
 			}
 		}
 	} else {
-		if val := string(item.Value); val == c.GetDefaultNilValue() {
+		if val, err := c.decode(string(item.Value)); err != nil {
+			return NewResult("", err)
+		} else if val == c.GetDefaultNilValue() {
 			return NewResult("", Nil)
 		} else {
-			return NewResult(val)
+			return newStoredResult(val)
 		}
 	}
 }
 
+// GetWithVersion Retrieve an item from the cache by key along with its version.
+func (c *MemcachedStore) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	item, err := c.conn(ctx).Get(c.PrefixKey(key))
+	switch err {
+	case nil:
+	case memcache.ErrCacheMiss:
+		return NewResult("", Nil), Version{}
+	default:
+		return NewResult("", err), Version{}
+	}
+
+	return c.storedResult(string(item.Value)), Version{exists: true, item: item}
+}
+
+// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+// report whether it was stored.
+func (c *MemcachedStore) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	if !version.exists {
+		err = c.conn(ctx).Add(&memcache.Item{Key: c.PrefixKey(key), Value: []byte(val), Expiration: memcachedExpiration(expire)})
+	} else if version.item == nil {
+		return false, nil
+	} else {
+		item := *version.item
+		item.Value, item.Expiration = []byte(val), memcachedExpiration(expire)
+		err = c.conn(ctx).CompareAndSwap(&item)
+	}
+
+	switch err {
+	case nil:
+		return true, nil
+	case memcache.ErrNotStored, memcache.ErrCASConflict, memcache.ErrCacheMiss:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
 // Set Store an item in the cache.
-func (c *MemcachedStore) Set(key string, value interface{}, expire time.Duration) error {
-	return c.client.Set(&memcache.Item{
+func (c *MemcachedStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	val, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+
+	return c.conn(ctx).Set(&memcache.Item{
 		Key:        c.PrefixKey(key),
-		Value:      []byte(conv.String(value)),
-		Expiration: int32(expire / time.Second),
+		Value:      []byte(val),
+		Expiration: memcachedExpiration(expire),
 	})
 }
 
 // SetMany Store multiple items in the cache for a given number of expire,Non-atomic operation
-func (c *MemcachedStore) SetMany(values map[string]interface{}, expire time.Duration) error {
+func (c *MemcachedStore) SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error {
 	for key, value := range values {
-		if err := c.Set(key, value, expire); err != nil {
+		if err := c.Set(ctx, key, value, expire); err != nil {
 			return err
 		}
 	}
@@ -192,21 +305,26 @@ func (c *MemcachedStore) SetMany(values map[string]interface{}, expire time.Dura
 }
 
 // Forever Store an item in the cache indefinitely.
-func (c *MemcachedStore) Forever(key string, value interface{}) error {
-	return c.Set(key, value, 0)
+func (c *MemcachedStore) Forever(ctx context.Context, key string, value interface{}) error {
+	return c.Set(ctx, key, value, 0)
 }
 
 // ForeverMany Store multiple items in the cache indefinitely.
-func (c *MemcachedStore) ForeverMany(values map[string]interface{}) error {
-	return c.SetMany(values, 0)
+func (c *MemcachedStore) ForeverMany(ctx context.Context, values map[string]interface{}) error {
+	return c.SetMany(ctx, values, 0)
 }
 
 // Add Store an item in the cache if the key does not exist.
-func (c *MemcachedStore) Add(key string, value interface{}, expire time.Duration) (bool, error) {
-	if err := c.client.Add(&memcache.Item{
+func (c *MemcachedStore) Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	if err = c.conn(ctx).Add(&memcache.Item{
 		Key:        c.PrefixKey(key),
-		Value:      []byte(conv.String(value)),
-		Expiration: int32(expire / time.Second),
+		Value:      []byte(val),
+		Expiration: memcachedExpiration(expire),
 	}); err != nil {
 		if err == memcache.ErrNotStored {
 			return false, nil
@@ -219,15 +337,15 @@ func (c *MemcachedStore) Add(key string, value interface{}, expire time.Duration
 }
 
 // Increment Increment the value of an item in the cache.
-func (c *MemcachedStore) Increment(key string, value int64) (int64, error) {
+func (c *MemcachedStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
 	if value < 0 {
-		return c.Decrement(key, 0-value)
+		return c.Decrement(ctx, key, 0-value)
 	}
 
-	newValue, err := c.client.Increment(c.PrefixKey(key), uint64(value))
+	newValue, err := c.conn(ctx).Increment(c.PrefixKey(key), uint64(value))
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
-			if _, err = c.Add(key, value, 0); err != nil {
+			if _, err = c.Add(ctx, key, value, 0); err != nil {
 				return 0, err
 			}
 
@@ -241,11 +359,11 @@ func (c *MemcachedStore) Increment(key string, value int64) (int64, error) {
 }
 
 // IncrementMany Increment the value of multiple items in the cache,Non-atomic operation
-func (c *MemcachedStore) IncrementMany(values map[string]int64) (map[string]int64, error) {
+func (c *MemcachedStore) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
 	var ret = make(map[string]int64)
 
 	for key, value := range values {
-		if newValue, err := c.Increment(key, value); err != nil {
+		if newValue, err := c.Increment(ctx, key, value); err != nil {
 			return ret, err
 		} else {
 			ret[key] = newValue
@@ -256,15 +374,15 @@ func (c *MemcachedStore) IncrementMany(values map[string]int64) (map[string]int6
 }
 
 // Decrement Decrement the value of an item in the cache.
-func (c *MemcachedStore) Decrement(key string, value int64) (int64, error) {
+func (c *MemcachedStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
 	if value < 0 {
-		return c.Increment(key, 0-value)
+		return c.Increment(ctx, key, 0-value)
 	}
 
-	newValue, err := c.client.Decrement(c.PrefixKey(key), uint64(value))
+	newValue, err := c.conn(ctx).Decrement(c.PrefixKey(key), uint64(value))
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
-			if _, err = c.Add(key, value, 0); err != nil {
+			if _, err = c.Add(ctx, key, value, 0); err != nil {
 				return 0, err
 			}
 
@@ -278,11 +396,11 @@ func (c *MemcachedStore) Decrement(key string, value int64) (int64, error) {
 }
 
 // DecrementMany Decrement the value of multiple items in the cache,Non-atomic operation
-func (c *MemcachedStore) DecrementMany(values map[string]int64) (map[string]int64, error) {
+func (c *MemcachedStore) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
 	var ret = make(map[string]int64)
 
 	for key, value := range values {
-		if newValue, err := c.Decrement(key, value); err != nil {
+		if newValue, err := c.Decrement(ctx, key, value); err != nil {
 			return ret, err
 		} else {
 			ret[key] = newValue
@@ -293,8 +411,8 @@ func (c *MemcachedStore) DecrementMany(values map[string]int64) (map[string]int6
 }
 
 // Forget Remove an item from the cache.
-func (c *MemcachedStore) Forget(key string) error {
-	if err := c.client.Delete(c.PrefixKey(key)); err != nil && err != memcache.ErrCacheMiss {
+func (c *MemcachedStore) Forget(ctx context.Context, key string) error {
+	if err := c.conn(ctx).Delete(c.PrefixKey(key)); err != nil && err != memcache.ErrCacheMiss {
 		return err
 	}
 
@@ -302,11 +420,11 @@ func (c *MemcachedStore) Forget(key string) error {
 }
 
 // ForgetMany Remove multiple items from the cache,Non-atomic operation
-func (c *MemcachedStore) ForgetMany(keys ...string) (int64, error) {
+func (c *MemcachedStore) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
 	var count int64 = 0
 
 	for _, key := range keys {
-		if err := c.Forget(key); err != nil {
+		if err := c.Forget(ctx, key); err != nil {
 			return count, err
 		} else {
 			count++
@@ -316,9 +434,17 @@ func (c *MemcachedStore) ForgetMany(keys ...string) (int64, error) {
 	return count, nil
 }
 
-// Expire Set expiration time for a key.
-func (c *MemcachedStore) Expire(key string, expire time.Duration) (bool, error) {
-	val, err := c.client.Get(c.PrefixKey(key))
+// Expire Set expiration time for a key, a non-positive expire removes the key as on the other stores,
+// since a zero expiration means never expire to memcached.
+func (c *MemcachedStore) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	var err error
+
+	if expire <= 0 {
+		err = c.conn(ctx).Delete(c.PrefixKey(key))
+	} else {
+		err = c.conn(ctx).Touch(c.PrefixKey(key), memcachedExpiration(expire))
+	}
+
 	if err != nil {
 		if err == memcache.ErrCacheMiss {
 			return false, nil
@@ -327,15 +453,11 @@ func (c *MemcachedStore) Expire(key string, expire time.Duration) (bool, error)
 		return false, err
 	}
 
-	if err = c.Set(key, val, expire); err != nil {
-		return false, err
-	}
-
 	return true, nil
 }
 
 // ExpireMany Expire Set expiration time for multiple key.
-func (c *MemcachedStore) ExpireMany(values map[string]time.Duration) (map[string]bool, error) {
+func (c *MemcachedStore) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
 	var (
 		ok  bool
 		err error
@@ -343,7 +465,7 @@ func (c *MemcachedStore) ExpireMany(values map[string]time.Duration) (map[string
 	)
 
 	for key, expire := range values {
-		if ok, err = c.Expire(key, expire); err != nil {
+		if ok, err = c.Expire(ctx, key, expire); err != nil {
 			return nil, err
 		} else {
 			ret[key] = ok
@@ -354,8 +476,8 @@ func (c *MemcachedStore) ExpireMany(values map[string]time.Duration) (map[string
 }
 
 // Flush Remove all items from the cache.
-func (c *MemcachedStore) Flush() error {
-	return c.client.DeleteAll()
+func (c *MemcachedStore) Flush(ctx context.Context) error {
+	return c.conn(ctx).DeleteAll()
 }
 
 // Lock Get a lock instance.
@@ -363,7 +485,172 @@ func (c *MemcachedStore) Lock(name string, time time.Duration) Lock {
 	return NewMemcachedLock(c.client, c.PrefixKey(name), time)
 }
 
+// RestoreLock Restore a lock instance using the owner token.
+func (c *MemcachedStore) RestoreLock(name string, owner string) Lock {
+	return NewMemcachedLock(c.client, c.PrefixKey(name), 0, owner)
+}
+
+// RWLock Get a read-write lock instance.
+func (c *MemcachedStore) RWLock(name string, time time.Duration) RWLock {
+	return NewMemcachedRWLock(c.client, c.PrefixKey(name), time)
+}
+
+// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+func (c *MemcachedStore) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return NewMemcachedSemaphore(c.client, c.PrefixKey(name), permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock, zero if never.
+func (c *MemcachedStore) FencingToken(ctx context.Context, name string) (int64, error) {
+	item, err := c.conn(ctx).Get(fencingKey(c.PrefixKey(name)))
+	if err != nil {
+		if err == memcache.ErrCacheMiss {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(string(item.Value)), 10, 64)
+}
+
+// Pipeline Get a pipeline queuing mixed operations, the operations are run by a bounded pool of workers.
+func (c *MemcachedStore) Pipeline() *Pipeline {
+	return newPipeline(runPipeline(c, c.pipelineWorkers))
+}
+
 // GetClient Get the memcached client instance.
 func (c *MemcachedStore) GetClient() interface{} {
 	return c.client
 }
+
+// Close Nothing to release, the memcached client holds no background goroutines and can't close its idle connections.
+func (c *MemcachedStore) Close() error {
+	return nil
+}
+
+// memcachedConn The memcached client with its calls bounded by a context.
+// The context bounds the wait only, a write isn't withdrawn when its context ends.
+type memcachedConn struct {
+	ctx    context.Context
+	client *Memcached
+}
+
+// conn Get the client with its calls bounded by the context.
+func (c *MemcachedStore) conn(ctx context.Context) memcachedConn {
+	return memcachedConn{ctx: ctx, client: c.client}
+}
+
+// Get Get an item bounded by the context.
+func (cn memcachedConn) Get(key string) (*memcache.Item, error) {
+	return memcachedCall(cn.ctx, func() (*memcache.Item, error) {
+		return cn.client.Get(key)
+	})
+}
+
+// GetMulti Get multiple items bounded by the context.
+func (cn memcachedConn) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	return memcachedCall(cn.ctx, func() (map[string]*memcache.Item, error) {
+		return cn.client.GetMulti(keys)
+	})
+}
+
+// Set Set an item bounded by the context.
+func (cn memcachedConn) Set(item *memcache.Item) error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.Set(item)
+	})
+}
+
+// Add Add an item bounded by the context.
+func (cn memcachedConn) Add(item *memcache.Item) error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.Add(item)
+	})
+}
+
+// CompareAndSwap Compare and swap an item bounded by the context.
+func (cn memcachedConn) CompareAndSwap(item *memcache.Item) error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.CompareAndSwap(item)
+	})
+}
+
+// Touch Update the expiration of an item bounded by the context.
+func (cn memcachedConn) Touch(key string, seconds int32) error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.Touch(key, seconds)
+	})
+}
+
+// Increment Increment a counter bounded by the context.
+func (cn memcachedConn) Increment(key string, delta uint64) (uint64, error) {
+	return memcachedCall(cn.ctx, func() (uint64, error) {
+		return cn.client.Increment(key, delta)
+	})
+}
+
+// Decrement Decrement a counter bounded by the context.
+func (cn memcachedConn) Decrement(key string, delta uint64) (uint64, error) {
+	return memcachedCall(cn.ctx, func() (uint64, error) {
+		return cn.client.Decrement(key, delta)
+	})
+}
+
+// Delete Delete an item bounded by the context.
+func (cn memcachedConn) Delete(key string) error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.Delete(key)
+	})
+}
+
+// DeleteAll Delete all items bounded by the context.
+func (cn memcachedConn) DeleteAll() error {
+	return memcachedDo(cn.ctx, func() error {
+		return cn.client.DeleteAll()
+	})
+}
+
+// memcachedDo Run a memcached call bounded by the context.
+func memcachedDo(ctx context.Context, fn func() error) error {
+	_, err := memcachedCall(ctx, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+
+	return err
+}
+
+// memcachedCall Run a memcached call bounded by the context. The memcached client takes no context,
+// so the call is left to finish in the background with its outcome discarded when the context ends first,
+// and it's still bounded by the timeout of the client. The call isn't withdrawn, a write may still
+// reach the server after ctx.Err() is returned.
+func memcachedCall[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	type result struct {
+		val T
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		val, err := fn()
+		done <- result{val: val, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.val, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
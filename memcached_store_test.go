@@ -0,0 +1,340 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 10:20 上午
+ * @Desc: memcached store test
+ */
+
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+type (
+	// fakeMemcached An in-process memcached server speaking the subset of the text protocol used by the client.
+	fakeMemcached struct {
+		mu    sync.Mutex
+		casid uint64
+		items map[string]*fakeMemcachedItem
+		conns map[net.Conn]struct{}
+	}
+
+	fakeMemcachedItem struct {
+		value    []byte
+		flags    string
+		casid    uint64
+		expireAt time.Time
+	}
+)
+
+// newFakeMemcached Start a fake memcached server, return its address.
+func newFakeMemcached(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeMemcached{items: make(map[string]*fakeMemcachedItem), conns: make(map[net.Conn]struct{})}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+
+		s.mu.Lock()
+		for conn := range s.conns {
+			_ = conn.Close()
+		}
+		s.mu.Unlock()
+	})
+
+	return ln.Addr().String()
+}
+
+// newMemcachedStore Create a memcached store on a fake memcached server.
+func newMemcachedStore(t *testing.T, opt *cache.MemcachedOptions) *cache.MemcachedStore {
+	if opt == nil {
+		opt = &cache.MemcachedOptions{Prefix: "cache"}
+	}
+	opt.Addrs = []string{newFakeMemcached(t)}
+
+	return cache.NewMemcachedStore(opt).(*cache.MemcachedStore)
+}
+
+// serve Answer the commands of a connection until it's closed.
+func (s *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		var reply string
+
+		switch args[0] {
+		case "get", "gets":
+			reply = s.get(args[1:])
+		case "set", "add", "replace", "cas":
+			if len(args) < 5 {
+				reply = "ERROR\r\n"
+				break
+			}
+
+			size, err := strconv.Atoi(args[4])
+			if err != nil {
+				reply = "CLIENT_ERROR bad data chunk\r\n"
+				break
+			}
+
+			data := make([]byte, size+2)
+			if _, err = io.ReadFull(rw, data); err != nil {
+				return
+			}
+
+			reply = s.store(args, data[:size])
+		case "delete":
+			reply = s.delete(args[1])
+		case "incr", "decr":
+			reply = s.incr(args[0] == "incr", args[1], args[2])
+		case "touch":
+			reply = s.touch(args[1], args[2])
+		case "flush_all":
+			s.mu.Lock()
+			s.items = make(map[string]*fakeMemcachedItem)
+			s.mu.Unlock()
+			reply = "OK\r\n"
+		case "version":
+			reply = "VERSION fake\r\n"
+		default:
+			reply = "ERROR\r\n"
+		}
+
+		if _, err = rw.WriteString(reply); err != nil {
+			return
+		}
+
+		if err = rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// load Return the live item of the key, must be called with the lock held.
+func (s *fakeMemcached) load(key string) *fakeMemcachedItem {
+	item, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+
+	if !item.expireAt.IsZero() && !time.Now().Before(item.expireAt) {
+		delete(s.items, key)
+		return nil
+	}
+
+	return item
+}
+
+// nextCAS Return a new cas id, must be called with the lock held.
+func (s *fakeMemcached) nextCAS() uint64 {
+	s.casid++
+	return s.casid
+}
+
+func (s *fakeMemcached) get(keys []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+
+	for _, key := range keys {
+		if item := s.load(key); item != nil {
+			b.WriteString("VALUE " + key + " " + item.flags + " " + strconv.Itoa(len(item.value)) + " " + strconv.FormatUint(item.casid, 10) + "\r\n")
+			b.Write(item.value)
+			b.WriteString("\r\n")
+		}
+	}
+
+	b.WriteString("END\r\n")
+
+	return b.String()
+}
+
+func (s *fakeMemcached) store(args []string, value []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		key      = args[1]
+		item     = s.load(key)
+		expireAt = fakeMemcachedExpireAt(args[3])
+	)
+
+	switch args[0] {
+	case "add":
+		if item != nil {
+			return "NOT_STORED\r\n"
+		}
+	case "replace":
+		if item == nil {
+			return "NOT_STORED\r\n"
+		}
+	case "cas":
+		if item == nil {
+			return "NOT_FOUND\r\n"
+		}
+
+		if len(args) < 6 || args[5] != strconv.FormatUint(item.casid, 10) {
+			return "EXISTS\r\n"
+		}
+	}
+
+	s.items[key] = &fakeMemcachedItem{value: value, flags: args[2], casid: s.nextCAS(), expireAt: expireAt}
+
+	return "STORED\r\n"
+}
+
+func (s *fakeMemcached) delete(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.load(key) == nil {
+		return "NOT_FOUND\r\n"
+	}
+
+	delete(s.items, key)
+
+	return "DELETED\r\n"
+}
+
+func (s *fakeMemcached) incr(incr bool, key, delta string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.load(key)
+	if item == nil {
+		return "NOT_FOUND\r\n"
+	}
+
+	d, err := strconv.ParseUint(delta, 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR invalid numeric delta argument\r\n"
+	}
+
+	n, err := strconv.ParseUint(string(item.value), 10, 64)
+	if err != nil {
+		return "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n"
+	}
+
+	switch {
+	case incr:
+		n += d
+	case d > n:
+		n = 0
+	default:
+		n -= d
+	}
+
+	item.value, item.casid = []byte(strconv.FormatUint(n, 10)), s.nextCAS()
+
+	return string(item.value) + "\r\n"
+}
+
+func (s *fakeMemcached) touch(key, exptime string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.load(key)
+	if item == nil {
+		return "NOT_FOUND\r\n"
+	}
+
+	item.expireAt = fakeMemcachedExpireAt(exptime)
+
+	return "TOUCHED\r\n"
+}
+
+// fakeMemcachedExpireAt Convert the memcached expiration to a deadline, the zero time never expires.
+func fakeMemcachedExpireAt(exptime string) time.Time {
+	n, _ := strconv.ParseInt(exptime, 10, 64)
+
+	switch {
+	case n == 0:
+		return time.Time{}
+	case n < 0:
+		return time.Unix(1, 0)
+	case n > 30*24*60*60:
+		return time.Unix(n, 0)
+	default:
+		return time.Now().Add(time.Duration(n) * time.Second)
+	}
+}
+
+func TestMemcachedStore_Expire(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemcachedStore(t, nil)
+	)
+
+	_ = store.Set(ctx, "name", "fuxiao", time.Minute)
+
+	if ok, err := store.Expire(ctx, "name", 0); err != nil || !ok {
+		t.Fatalf("mc: expected the key to be expired, got %v, %v", ok, err)
+	}
+
+	if err := store.Get(ctx, "name").Err(); err != cache.Nil {
+		t.Fatalf("mc: expected a non-positive expire to remove the key, got %v", err)
+	}
+
+	if ok, err := store.Expire(ctx, "name", -time.Second); err != nil || ok {
+		t.Fatalf("mc: expected a missing key not to be expired, got %v, %v", ok, err)
+	}
+
+	// a sub-second expiration rounds up to a second instead of never expiring
+	_ = store.Set(ctx, "name", "fuxiao", 200*time.Millisecond)
+	_ = store.Set(ctx, "touched", "fuxiao", time.Minute)
+
+	if ok, _ := store.Expire(ctx, "touched", 200*time.Millisecond); !ok {
+		t.Fatal("mc: expected the key to be expired")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	for _, key := range []string{"name", "touched"} {
+		if err := store.Get(ctx, key).Err(); err != cache.Nil {
+			t.Fatalf("mc: expected %s to expire, got %v", key, err)
+		}
+	}
+}
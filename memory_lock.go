@@ -0,0 +1,87 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 10:48 上午
+ * @Desc: a memory lock instance
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type MemoryLock struct {
+	BaseLock
+	store *MemoryStore
+}
+
+// NewMemoryLock Create a memory lock instance.
+func NewMemoryLock(store *MemoryStore, name string, time time.Duration, owner ...string) Lock {
+	return &MemoryLock{
+		BaseLock: newBaseLock(name, time, owner...),
+		store:    store,
+	}
+}
+
+// Acquire Attempt to acquire the lock.
+func (l *MemoryLock) Acquire(ctx context.Context) (bool, error) {
+	token, ok := l.store.addLock(l.name, l.owner, l.time, fencingKey(l.name))
+	if ok {
+		l.setToken(token)
+	}
+
+	return ok, nil
+}
+
+// Release Release the lock if it is still owned by the current owner.
+func (l *MemoryLock) Release(ctx context.Context) (bool, error) {
+	l.unwatch()
+
+	return l.store.compareAndDeleteLock(l.name, l.owner), nil
+}
+
+// ForceRelease Release the lock regardless of ownership.
+func (l *MemoryLock) ForceRelease(ctx context.Context) error {
+	l.unwatch()
+	l.store.deleteLock(l.name)
+
+	return nil
+}
+
+// Get Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+func (l *MemoryLock) Get(ctx context.Context, callbacks ...func() error) (bool, error) {
+	return getLock(ctx, l, callbacks)
+}
+
+// Block Attempt to acquire the lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the lock.
+func (l *MemoryLock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockLock(ctx, l, l.name, wait, callbacks)
+}
+
+// Extend Refresh the lease of the lock to the given ttl if it is still owned by the current owner.
+func (l *MemoryLock) Extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	ttl, err := l.extendTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+
+	return l.store.compareAndExpireLock(l.name, l.owner, ttl), nil
+}
+
+// Watch Renew the lease of the lock in the background until the lock is released or the context ends.
+func (l *MemoryLock) Watch(ctx context.Context) <-chan error {
+	return l.watch(ctx, l.Extend)
+}
+
+// NewMemoryRWLock Create a memory read-write lock instance.
+func NewMemoryRWLock(store *MemoryStore, name string, time time.Duration, owner ...string) RWLock {
+	return newCASRWLock(store.updateLock, name, time, owner...)
+}
+
+// NewMemorySemaphore Create a memory semaphore instance.
+func NewMemorySemaphore(store *MemoryStore, name string, permits int, time time.Duration, owner ...string) Semaphore {
+	return newCASSemaphore(store.updateLock, name, permits, time, owner...)
+}
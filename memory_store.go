@@ -0,0 +1,822 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 10:12 上午
+ * @Desc: a memory store instance
+ */
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dobyte/cache/internal/conv"
+)
+
+const defaultCleanupInterval = time.Minute
+
+type (
+	MemoryStore struct {
+		BaseStore
+		mu         sync.Mutex
+		items      map[string]*list.Element
+		lru        *list.List
+		locks      map[string]*memoryItem
+		fences     map[string]int64
+		size       int64
+		revision   uint64
+		maxEntries int
+		maxBytes   int64
+		done       chan struct{}
+		closeOnce  sync.Once
+	}
+
+	MemoryOptions struct {
+		Prefix           string
+		DefaultNilValue  string
+		DefaultNilExpire int64
+		// MaxEntries The maximum number of items kept in memory, zero means no limit.
+		MaxEntries int
+		// Codec The codec encoding the values, the values are converted to plain strings if it's nil.
+		Codec Codec
+		// Keyring The keyring sealing the values, the values are stored in plain if it's nil.
+		Keyring *Keyring
+		// MaxBytes The maximum total size of keys and values kept in memory, zero means no limit,
+		// an item larger than the limit on its own is rejected with cache.ErrValueTooLarge.
+		MaxBytes int64
+		// CleanupInterval The interval of the background expired items cleanup, a negative value disables it.
+		CleanupInterval time.Duration
+	}
+
+	memoryItem struct {
+		key   string
+		value string
+		// revision The revision of the value, bumped by each write so that a value written again is told apart.
+		revision uint64
+		expireAt int64
+	}
+)
+
+// NewMemoryStore Create a memory store instance.
+func NewMemoryStore(opt *MemoryOptions) Store {
+	c := &MemoryStore{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		locks:      make(map[string]*memoryItem),
+		fences:     make(map[string]int64),
+		maxEntries: opt.MaxEntries,
+		maxBytes:   opt.MaxBytes,
+		done:       make(chan struct{}),
+	}
+	c.SetPrefix(opt.Prefix)
+	c.SetDefaultNilValue(opt.DefaultNilValue)
+	c.SetDefaultNilExpire(opt.DefaultNilExpire)
+	c.SetCodec(opt.Codec)
+	c.SetKeyring(opt.Keyring)
+
+	interval := opt.CleanupInterval
+	if interval == 0 {
+		interval = defaultCleanupInterval
+	}
+
+	if interval > 0 {
+		go c.janitor(interval)
+	}
+
+	return c
+}
+
+// Has Determine if an item exists in the cache, a held lock exists under its name as in the other stores.
+func (c *MemoryStore) Has(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.exists(c.PrefixKey(key)), nil
+}
+
+// HasMany Determine if multiple item exists in the cache.
+func (c *MemoryStore) HasMany(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		ret[key] = c.exists(c.PrefixKey(key))
+	}
+
+	return ret, nil
+}
+
+// Get Retrieve an item from the cache by key.
+func (c *MemoryStore) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	val, ok := c.value(c.PrefixKey(key))
+	if !ok {
+		if len(defaultValue) > 0 {
+			return NewResult(conv.String(defaultValue[0]))
+		}
+
+		return NewResult("", Nil)
+	}
+
+	c.resealOnRead(key, val)
+
+	return c.storedResult(val)
+}
+
+// GetMany Retrieve multiple items from the cache by key.
+func (c *MemoryStore) GetMany(ctx context.Context, keys ...string) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(keys))
+
+	c.mu.Lock()
+	for _, key := range keys {
+		if item, ok := c.load(c.PrefixKey(key)); ok {
+			values[key] = item.value
+		}
+	}
+	c.mu.Unlock()
+
+	ret := make(map[string]Result, len(keys))
+	for _, key := range keys {
+		if val, ok := values[key]; ok {
+			c.resealOnRead(key, val)
+			ret[key] = c.storedResult(val)
+		} else {
+			ret[key] = NewResult("", Nil)
+		}
+	}
+
+	return ret, nil
+}
+
+// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn.
+func (c *MemoryStore) GetManySet(ctx context.Context, keys []string, fn defaultValuesFunc) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	raws := make(map[string]string, len(keys))
+
+	c.mu.Lock()
+	for _, key := range keys {
+		if item, ok := c.load(c.PrefixKey(key)); ok {
+			raws[key] = item.value
+		}
+	}
+	c.mu.Unlock()
+
+	for key, raw := range raws {
+		c.resealOnRead(key, raw)
+	}
+
+	return c.getManySet(ctx, c, keys, raws, fn)
+}
+
+// GetSet Retrieve or set an item from the cache by key.
+func (c *MemoryStore) GetSet(ctx context.Context, key string, fn defaultValueFunc) Result {
+	prefixedKey := c.PrefixKey(key)
+
+	if val, ok := c.value(prefixedKey); ok {
+		c.resealOnRead(key, val)
+
+		return c.storedResult(val)
+	}
+
+	switch ret, err := c.shareCall(ctx, key, func() (interface{}, error) {
+		val, expire, err := fn()
+		return defaultValueRet{
+			val:    val,
+			expire: expire,
+		}, err
+	}); err {
+	case nil:
+		ret := ret.(defaultValueRet)
+		val, err := encodeValue(c.GetCodec(), ret.val)
+		if err != nil {
+			return NewResult("", err)
+		}
+		return newStoredResult(val, nil, c.Set(ctx, key, encodedValue(val), ret.expire))
+	case Nil:
+		ret := ret.(defaultValueRet)
+		expire := c.GetDefaultNilExpire()
+		if ret.expire > 0 {
+			expire = ret.expire
+		}
+		return NewResult("", Nil, c.Set(ctx, key, c.GetDefaultNilValue(), expire))
+	default:
+		return NewResult("", err)
+	}
+}
+
+// GetWithVersion Retrieve an item from the cache by key along with its version.
+func (c *MemoryStore) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	raw, revision, ok := c.revisionOf(c.PrefixKey(key))
+	if !ok {
+		return NewResult("", Nil), Version{}
+	}
+
+	return c.storedResult(raw), Version{exists: true, revision: revision}
+}
+
+// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+// report whether it was stored.
+func (c *MemoryStore) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	prefixedKey := c.PrefixKey(key)
+	if !c.fits(prefixedKey, val) {
+		return false, ErrValueTooLarge
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.load(prefixedKey)
+	if ok != version.exists || (ok && item.revision != version.revision) {
+		return false, nil
+	}
+
+	c.store(prefixedKey, val, expire)
+
+	return true, nil
+}
+
+// Set Store an item in the cache.
+func (c *MemoryStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	val, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+
+	prefixedKey := c.PrefixKey(key)
+	if !c.fits(prefixedKey, val) {
+		return ErrValueTooLarge
+	}
+
+	c.mu.Lock()
+	c.store(prefixedKey, val, expire)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// SetMany Store multiple items in the cache for a given number of expire.
+func (c *MemoryStore) SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error {
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		val, err := c.encode(value)
+		if err != nil {
+			return err
+		}
+
+		prefixedKey := c.PrefixKey(key)
+		if !c.fits(prefixedKey, val) {
+			return ErrValueTooLarge
+		}
+		encoded[prefixedKey] = val
+	}
+
+	c.mu.Lock()
+	for key, val := range encoded {
+		c.store(key, val, expire)
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Forever Store an item in the cache indefinitely.
+func (c *MemoryStore) Forever(ctx context.Context, key string, value interface{}) error {
+	return c.Set(ctx, key, value, 0)
+}
+
+// ForeverMany Store multiple items in the cache indefinitely.
+func (c *MemoryStore) ForeverMany(ctx context.Context, values map[string]interface{}) error {
+	return c.SetMany(ctx, values, 0)
+}
+
+// Add Store an item in the cache if the key does not exist.
+func (c *MemoryStore) Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	prefixedKey := c.PrefixKey(key)
+	if !c.fits(prefixedKey, val) {
+		return false, ErrValueTooLarge
+	}
+
+	return c.add(prefixedKey, val, expire), nil
+}
+
+// Increment Increment the value of an item in the cache.
+func (c *MemoryStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.incr(c.PrefixKey(key), value)
+}
+
+// IncrementMany Increment the value of multiple items in the cache.
+func (c *MemoryStore) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make(map[string]int64, len(values))
+	for key, value := range values {
+		newValue, err := c.incr(c.PrefixKey(key), value)
+		if err != nil {
+			return nil, err
+		}
+		ret[key] = newValue
+	}
+
+	return ret, nil
+}
+
+// Decrement Decrement the value of an item in the cache.
+func (c *MemoryStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	return c.Increment(ctx, key, 0-value)
+}
+
+// DecrementMany Decrement the value of multiple items in the cache.
+func (c *MemoryStore) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	negated := make(map[string]int64, len(values))
+	for key, value := range values {
+		negated[key] = 0 - value
+	}
+
+	return c.IncrementMany(ctx, negated)
+}
+
+// Forget Remove an item from the cache.
+func (c *MemoryStore) Forget(ctx context.Context, key string) error {
+	c.delete(c.PrefixKey(key))
+
+	return nil
+}
+
+// ForgetMany Remove multiple items from the cache.
+func (c *MemoryStore) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
+	var count int64
+
+	for _, key := range keys {
+		if c.delete(c.PrefixKey(key)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Expire Set expiration time for a key.
+func (c *MemoryStore) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.expire(c.PrefixKey(key), expire), nil
+}
+
+// ExpireMany Set expiration time for multiple key.
+func (c *MemoryStore) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make(map[string]bool, len(values))
+	for key, expire := range values {
+		ret[key] = c.expire(c.PrefixKey(key), expire)
+	}
+
+	return ret, nil
+}
+
+// Flush Remove all items from the cache.
+func (c *MemoryStore) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	c.items = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Lock Get a lock instance.
+func (c *MemoryStore) Lock(name string, time time.Duration) Lock {
+	return NewMemoryLock(c, c.PrefixKey(name), time)
+}
+
+// RestoreLock Restore a lock instance using the owner token.
+func (c *MemoryStore) RestoreLock(name string, owner string) Lock {
+	return NewMemoryLock(c, c.PrefixKey(name), 0, owner)
+}
+
+// RWLock Get a read-write lock instance.
+func (c *MemoryStore) RWLock(name string, time time.Duration) RWLock {
+	return NewMemoryRWLock(c, c.PrefixKey(name), time)
+}
+
+// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+func (c *MemoryStore) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return NewMemorySemaphore(c, c.PrefixKey(name), permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock, zero if never.
+func (c *MemoryStore) FencingToken(ctx context.Context, name string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.fences[fencingKey(c.PrefixKey(name))], nil
+}
+
+// Pipeline Get a pipeline queuing mixed operations, the operations are run one by one.
+func (c *MemoryStore) Pipeline() *Pipeline {
+	return newPipeline(runPipeline(c, 1))
+}
+
+// GetClient Get the memory store itself, there is no underlying client.
+func (c *MemoryStore) GetClient() interface{} {
+	return c
+}
+
+// Len Return the number of items in the cache, including the expired but not yet removed items.
+func (c *MemoryStore) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Size Return the total size of keys and values in the cache.
+func (c *MemoryStore) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// Close Stop the background expired items cleanup.
+func (c *MemoryStore) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return nil
+}
+
+// add Store an item by the prefixed key if the key does not exist.
+func (c *MemoryStore) add(key string, value string, expire time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.load(key); ok {
+		return false
+	}
+
+	c.store(key, value, expire)
+
+	return true
+}
+
+// addLock Store a lock by the prefixed key if it's not held, and increment the fencing counter along with it.
+// The locks and the fencing counters are kept apart from the items, so that they're never evicted nor flushed.
+func (c *MemoryStore) addLock(key string, value string, expire time.Duration, fenceKey string) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.loadLock(key); ok {
+		return 0, false
+	}
+
+	c.locks[key] = &memoryItem{key: key, value: value, expireAt: expireAt(expire)}
+	c.fences[fenceKey]++
+
+	return c.fences[fenceKey], true
+}
+
+// rewrite Replace the value of an item keeping its expiration if the value is unchanged.
+func (c *MemoryStore) rewrite(key string, old string, new string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.load(c.PrefixKey(key))
+	if !ok || item.value != old {
+		return false
+	}
+
+	c.size += int64(len(new) - len(old))
+	item.value = new
+	c.evict()
+
+	return true
+}
+
+// resealOnRead Seal a value read with the primary key again in place if it was sealed with a rotated key.
+func (c *MemoryStore) resealOnRead(key string, val string) {
+	if sealed, ok := c.reseal(val); ok {
+		c.rewrite(key, val, sealed)
+	}
+}
+
+// delete Remove an item by the prefixed key, report whether the item existed.
+func (c *MemoryStore) delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.load(key); !ok {
+		return false
+	}
+
+	c.remove(c.items[key])
+
+	return true
+}
+
+// deleteLock Remove a lock by the prefixed key.
+func (c *MemoryStore) deleteLock(key string) {
+	c.mu.Lock()
+	delete(c.locks, key)
+	c.mu.Unlock()
+}
+
+// compareAndDeleteLock Remove a lock by the prefixed key if its value equals to the given value.
+func (c *MemoryStore) compareAndDeleteLock(key string, value string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.loadLock(key); !ok || item.value != value {
+		return false
+	}
+
+	delete(c.locks, key)
+
+	return true
+}
+
+// compareAndExpireLock Set expiration time for a lock by the prefixed key if its value equals to the given value.
+func (c *MemoryStore) compareAndExpireLock(key string, value string, expire time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.loadLock(key)
+	if !ok || item.value != value {
+		return false
+	}
+
+	if expire <= 0 {
+		delete(c.locks, key)
+	} else {
+		item.expireAt = expireAt(expire)
+	}
+
+	return true
+}
+
+// updateLock Atomically update the value of a lock by the prefixed key, such as the holders of a shared lock.
+func (c *MemoryStore) updateLock(ctx context.Context, key string, fn func(value []byte) ([]byte, time.Duration, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var current []byte
+	if item, ok := c.loadLock(key); ok {
+		current = []byte(item.value)
+	}
+
+	value, expire, err := fn(current)
+	if err != nil {
+		if err == errNoUpdate {
+			return nil
+		}
+
+		return err
+	}
+
+	if value == nil {
+		delete(c.locks, key)
+	} else {
+		c.locks[key] = &memoryItem{key: key, value: string(value), expireAt: expireAt(expire)}
+	}
+
+	return nil
+}
+
+// exists Determine if an item or a lock exists by the prefixed key, must hold the lock.
+func (c *MemoryStore) exists(key string) bool {
+	if _, ok := c.load(key); ok {
+		return true
+	}
+
+	_, ok := c.loadLock(key)
+
+	return ok
+}
+
+// loadLock Retrieve an unexpired lock by the prefixed key, must hold the lock.
+func (c *MemoryStore) loadLock(key string) (*memoryItem, bool) {
+	item, ok := c.locks[key]
+	if !ok {
+		return nil, false
+	}
+
+	if item.expired(time.Now().UnixNano()) {
+		delete(c.locks, key)
+		return nil, false
+	}
+
+	return item, true
+}
+
+// load Retrieve an unexpired item by the prefixed key and mark it as recently used, must hold the lock.
+func (c *MemoryStore) load(key string) (*memoryItem, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*memoryItem)
+	if item.expired(time.Now().UnixNano()) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return item, true
+}
+
+// value Get the value of an item by the prefixed key.
+func (c *MemoryStore) value(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.load(key); ok {
+		return item.value, true
+	}
+
+	return "", false
+}
+
+// revisionOf Get the value of an item by the prefixed key along with its revision.
+func (c *MemoryStore) revisionOf(key string) (string, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.load(key); ok {
+		return item.value, item.revision, true
+	}
+
+	return "", 0, false
+}
+
+// fits Determine if an item fits in the byte limit at all, a larger item would be evicted as soon as it's stored.
+func (c *MemoryStore) fits(key string, value string) bool {
+	return c.maxBytes <= 0 || int64(len(key)+len(value)) <= c.maxBytes
+}
+
+// store Store an item by the prefixed key and evict the least recently used items if needed, must hold the lock.
+func (c *MemoryStore) store(key string, value string, expire time.Duration) {
+	expireAt := expireAt(expire)
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*memoryItem)
+		c.size += int64(len(value) - len(item.value))
+		item.value = value
+		item.revision = c.nextRevision()
+		item.expireAt = expireAt
+		c.lru.MoveToFront(elem)
+	} else {
+		c.items[key] = c.lru.PushFront(&memoryItem{key: key, value: value, revision: c.nextRevision(), expireAt: expireAt})
+		c.size += int64(len(key) + len(value))
+	}
+
+	c.evict()
+}
+
+// nextRevision Return a new revision of the values, must hold the lock.
+func (c *MemoryStore) nextRevision() uint64 {
+	c.revision++
+	return c.revision
+}
+
+// incr Increment the integer value of an item by the prefixed key and keep its expiration, must hold the lock.
+func (c *MemoryStore) incr(key string, value int64) (int64, error) {
+	item, ok := c.load(key)
+	if !ok {
+		c.store(key, strconv.FormatInt(value, 10), 0)
+		return value, nil
+	}
+
+	old, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+
+	newValue := old + value
+	val := strconv.FormatInt(newValue, 10)
+	c.size += int64(len(val) - len(item.value))
+	item.value = val
+	item.revision = c.nextRevision()
+	c.evict()
+
+	return newValue, nil
+}
+
+// expire Set expiration time for an item by the prefixed key, a non-positive expire removes it, must hold the lock.
+func (c *MemoryStore) expire(key string, expire time.Duration) bool {
+	item, ok := c.load(key)
+	if !ok {
+		return false
+	}
+
+	if expire <= 0 {
+		c.remove(c.items[key])
+	} else {
+		item.expireAt = time.Now().Add(expire).UnixNano()
+	}
+
+	return true
+}
+
+// evict Remove the least recently used items until the limits are satisfied, must hold the lock.
+func (c *MemoryStore) evict() {
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+// remove Remove an element from the cache, must hold the lock.
+func (c *MemoryStore) remove(elem *list.Element) {
+	item := c.lru.Remove(elem).(*memoryItem)
+	delete(c.items, item.key)
+	c.size -= int64(len(item.key) + len(item.value))
+}
+
+// deleteExpired Remove all expired items from the cache.
+func (c *MemoryStore) deleteExpired() {
+	now := time.Now().UnixNano()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*memoryItem).expired(now) {
+			c.remove(elem)
+		}
+		elem = prev
+	}
+
+	for key, item := range c.locks {
+		if item.expired(now) {
+			delete(c.locks, key)
+		}
+	}
+}
+
+// janitor Remove expired items periodically until the store is closed.
+func (c *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// expireAt Return the deadline of an expiration in nanoseconds since the epoch, zero if never.
+func expireAt(expire time.Duration) int64 {
+	if expire <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(expire).UnixNano()
+}
+
+// expired Determine if the item is expired.
+func (i *memoryItem) expired(now int64) bool {
+	return i.expireAt > 0 && i.expireAt <= now
+}
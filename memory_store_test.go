@@ -0,0 +1,281 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 11:20 上午
+ * @Desc: memory store test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func newMemoryStore(opt *cache.MemoryOptions) *cache.MemoryStore {
+	if opt == nil {
+		opt = &cache.MemoryOptions{Prefix: "cache"}
+	}
+
+	return cache.NewMemoryStore(opt).(*cache.MemoryStore)
+}
+
+func TestMemoryStore_SetGet(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(nil)
+	)
+	defer store.Close()
+
+	if err := store.Set(ctx, "name", "fuxiao", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, err := store.Get(ctx, "name").Result(); err != nil || val != "fuxiao" {
+		t.Fatalf("memory: unexpected value %q, %v", val, err)
+	}
+
+	if err := store.Get(ctx, "age").Err(); err != cache.Nil {
+		t.Fatalf("memory: expected cache.Nil, got %v", err)
+	}
+
+	if val := store.Get(ctx, "age", 30).Val(); val != "30" {
+		t.Fatalf("memory: unexpected default value %q", val)
+	}
+
+	if ok, _ := store.Has(ctx, "name"); !ok {
+		t.Fatal("memory: expected the item to exist")
+	}
+}
+
+func TestMemoryStore_Expire(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: 10 * time.Millisecond})
+	)
+	defer store.Close()
+
+	_ = store.Set(ctx, "a", 1, 20*time.Millisecond)
+	_ = store.Forever(ctx, "b", 2)
+
+	if ok, _ := store.Expire(ctx, "b", 20*time.Millisecond); !ok {
+		t.Fatal("memory: expected the expiration to be set")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if store.Len() != 0 {
+		t.Fatalf("memory: expected the janitor to remove expired items, %d left", store.Len())
+	}
+
+	if ok, _ := store.Has(ctx, "a"); ok {
+		t.Fatal("memory: expected the item to be expired")
+	}
+}
+
+func TestMemoryStore_Evict(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{MaxEntries: 2, CleanupInterval: -1})
+	)
+
+	_ = store.Set(ctx, "a", 1, 0)
+	_ = store.Set(ctx, "b", 2, 0)
+	_ = store.Get(ctx, "a")
+	_ = store.Set(ctx, "c", 3, 0)
+
+	ret, _ := store.HasMany(ctx, "a", "b", "c")
+	if !ret["a"] || ret["b"] || !ret["c"] {
+		t.Fatalf("memory: expected the least recently used item to be evicted, got %v", ret)
+	}
+
+	store = newMemoryStore(&cache.MemoryOptions{MaxBytes: 20, CleanupInterval: -1})
+	for i := 0; i < 10; i++ {
+		_ = store.Set(ctx, fmt.Sprintf("k%d", i), "value", 0)
+	}
+
+	if store.Size() > 20 {
+		t.Fatalf("memory: expected the size to be bounded, got %d", store.Size())
+	}
+
+	if err := store.Set(ctx, "large", strings.Repeat("x", 20), 0); err != cache.ErrValueTooLarge {
+		t.Fatalf("memory: expected cache.ErrValueTooLarge, got %v", err)
+	}
+
+	if ok, err := store.Add(ctx, "large", strings.Repeat("x", 20), 0); ok || err != cache.ErrValueTooLarge {
+		t.Fatalf("memory: expected cache.ErrValueTooLarge, got %v", err)
+	}
+}
+
+func TestMemoryStore_Close(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c := cache.NewCache(&cache.Options{Driver: cache.MemoryDriver})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatal("memory: expected the cleanup goroutine to stop on close")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMemoryStore_Increment(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+	)
+
+	if val, _ := store.Increment(ctx, "count", 5); val != 5 {
+		t.Fatalf("memory: unexpected value %d", val)
+	}
+
+	if val, _ := store.Decrement(ctx, "count", 2); val != 3 {
+		t.Fatalf("memory: unexpected value %d", val)
+	}
+
+	_ = store.Set(ctx, "name", "fuxiao", 0)
+	if _, err := store.Increment(ctx, "name", 1); err != cache.ErrNotInteger {
+		t.Fatalf("memory: expected cache.ErrNotInteger, got %v", err)
+	}
+}
+
+func TestMemoryStore_GetSet(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		calls int
+	)
+
+	for i := 0; i < 2; i++ {
+		rst := store.GetSet(ctx, "missing", func() (interface{}, time.Duration, error) {
+			calls++
+			return nil, 0, cache.Nil
+		})
+		if rst.Err() != cache.Nil {
+			t.Fatalf("memory: expected cache.Nil, got %v", rst.Err())
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("memory: expected the nil value to be cached, loader called %d times", calls)
+	}
+}
+
+func TestMemoryStore_Lock(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+	)
+
+	lock := store.Lock("job", time.Minute)
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("memory: expected to acquire the lock")
+	}
+
+	other := store.Lock("job", time.Minute)
+	if ok, _ := other.Acquire(ctx); ok {
+		t.Fatal("memory: expected the lock to be held")
+	}
+
+	if ok, _ := other.Release(ctx); ok {
+		t.Fatal("memory: expected the lock not to be released by another owner")
+	}
+
+	if ok, _ := store.RestoreLock("job", lock.Owner()).Release(ctx); !ok {
+		t.Fatal("memory: expected the restored lock to be released")
+	}
+
+	if ok, _ := other.Acquire(ctx); !ok {
+		t.Fatal("memory: expected to acquire the released lock")
+	}
+
+	if err := lock.ForceRelease(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("memory: expected to acquire the force released lock")
+	}
+}
+
+func TestMemoryStore_LockNotEvicted(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = newMemoryStore(&cache.MemoryOptions{MaxEntries: 1, CleanupInterval: -1})
+		lock  = store.Lock("job", time.Minute)
+	)
+
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("memory: expected to acquire the lock")
+	}
+
+	// the items evict each other and the flush removes them all, the lock and its fencing counter stay
+	_ = store.Set(ctx, "a", 1, time.Minute)
+	_ = store.Set(ctx, "b", 2, time.Minute)
+	_ = store.Flush(ctx)
+
+	if ok, _ := store.Lock("job", time.Minute).Acquire(ctx); ok {
+		t.Fatal("memory: expected the lock to be held after the eviction and the flush")
+	}
+
+	if ok, _ := lock.Release(ctx); !ok {
+		t.Fatal("memory: expected the lock to be released by its owner")
+	}
+
+	if ok, _ := lock.Acquire(ctx); !ok || lock.Token() != 2 {
+		t.Fatalf("memory: expected the fencing token to keep growing, got %d", lock.Token())
+	}
+}
+
+func TestMemoryStore_GetSetShared(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		a       = newMemoryStore(&cache.MemoryOptions{Prefix: "a", CleanupInterval: -1})
+		b       = newMemoryStore(&cache.MemoryOptions{Prefix: "b", CleanupInterval: -1})
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan cache.Result)
+	)
+
+	go func() {
+		done <- a.GetSet(ctx, "name", func() (interface{}, time.Duration, error) {
+			close(started)
+			<-release
+			return "a", time.Minute, nil
+		})
+	}()
+	<-started
+
+	if val := b.GetSet(ctx, "name", func() (interface{}, time.Duration, error) {
+		return "b", time.Minute, nil
+	}).Val(); val != "b" {
+		t.Fatalf("memory: expected the loader of the other prefix to run, got %q", val)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	if err := a.GetSet(waitCtx, "name", nil).Err(); err != context.DeadlineExceeded {
+		t.Fatalf("memory: expected the waiter to abandon the wait, got %v", err)
+	}
+
+	close(release)
+
+	if val := (<-done).Val(); val != "a" {
+		t.Fatalf("memory: expected the loaded value, got %q", val)
+	}
+
+	if stats := a.SharedCallStats(); stats.Calls != 2 || stats.Shared != 1 {
+		t.Fatalf("memory: unexpected shared call stats %+v", stats)
+	}
+}
@@ -0,0 +1,103 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 3:40 下午
+ * @Desc: value metadata define
+ */
+
+package cache
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// metaMarker The first byte of a payload carrying metadata, followed by the number of the metadata fields,
+// the fields as uvarints and the value. The fields unknown to a reader are skipped.
+const metaMarker = '\x03'
+
+type (
+	// valueMeta The metadata stored alongside a value.
+	valueMeta struct {
+		// writtenAt The time the value was written, in milliseconds since the epoch.
+		writtenAt int64
+		// delta The time the value took to load, in milliseconds rounded up.
+		delta int64
+		// expireAt The time the value logically expires, in milliseconds since the epoch, zero if never.
+		expireAt int64
+	}
+
+	// metaValue A value to be stored alongside its metadata.
+	metaValue struct {
+		value interface{}
+		meta  valueMeta
+	}
+)
+
+// newMetaValue Wrap a value to be stored along with the time it's written.
+func newMetaValue(value interface{}) *metaValue {
+	return &metaValue{
+		value: value,
+		meta:  valueMeta{writtenAt: time.Now().UnixMilli()},
+	}
+}
+
+// age Get the time elapsed since the value was written.
+func (m valueMeta) age() time.Duration {
+	return time.Since(time.UnixMilli(m.writtenAt))
+}
+
+// fields Get the fields of the metadata in the order they are stored.
+func (m valueMeta) fields() []int64 {
+	return []int64{m.writtenAt, m.delta, m.expireAt}
+}
+
+// wrapMeta Put the metadata in front of an encoded value.
+func wrapMeta(val string, meta valueMeta) string {
+	fields := meta.fields()
+
+	buf := make([]byte, 0, len(val)+2+len(fields)*binary.MaxVarintLen64)
+	buf = append(buf, metaMarker, byte(len(fields)))
+	for _, field := range fields {
+		buf = binary.AppendUvarint(buf, uint64(field))
+	}
+
+	return string(append(buf, val...))
+}
+
+// parseMeta Split a value carrying metadata into the value and the metadata, report whether it carries any.
+func parseMeta(val string) (string, *valueMeta, bool) {
+	if len(val) < 2 || val[0] != metaMarker {
+		return val, nil, false
+	}
+
+	var (
+		meta   = &valueMeta{}
+		fields = []*int64{&meta.writtenAt, &meta.delta, &meta.expireAt}
+		data   = []byte(val[2:])
+	)
+
+	for i := 0; i < int(val[1]); i++ {
+		field, size := binary.Uvarint(data)
+		if size <= 0 {
+			return val, nil, false
+		}
+
+		if i < len(fields) {
+			*fields[i] = int64(field)
+		}
+		data = data[size:]
+	}
+
+	return string(data), meta, true
+}
+
+// metaOf Get the metadata stored alongside the value of a result.
+func metaOf(rst Result) (*valueMeta, bool) {
+	r, ok := rst.(*result)
+	if !ok || r.meta == nil {
+		return nil, false
+	}
+
+	return r.meta, true
+}
@@ -0,0 +1,278 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 7:20 下午
+ * @Desc: a pipeline queuing mixed operations
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultPipelineWorkers The default number of workers running the operations of a pipeline on the stores without pipelining.
+const defaultPipelineWorkers = 8
+
+const (
+	pipelineGet = iota
+	pipelineSet
+	pipelineAdd
+	pipelineIncrement
+	pipelineExpire
+	pipelineForget
+)
+
+type (
+	// Pipeline A builder queuing mixed operations to run them all at once, each operation returns a future
+	// holding its result once the pipeline is executed.
+	Pipeline struct {
+		mu   sync.Mutex
+		ops  []*pipelineOp
+		exec pipelineExecFunc
+	}
+
+	// Future The result of an operation queued into a pipeline.
+	Future[T any] struct {
+		val  T
+		err  error
+		done bool
+	}
+
+	ResultFuture = Future[Result]
+	IntFuture    = Future[int64]
+	BoolFuture   = Future[bool]
+	StatusFuture = Future[struct{}]
+
+	// pipelineExecFunc Run the queued operations against a store and resolve their futures.
+	pipelineExecFunc func(ctx context.Context, ops []*pipelineOp) error
+
+	pipelineOp struct {
+		cmd    int
+		key    string
+		value  interface{}
+		expire time.Duration
+		err    error
+		future futureResolver
+	}
+
+	futureResolver interface {
+		resolve(val interface{}, err error)
+	}
+)
+
+// newPipeline Create a pipeline running the queued operations with the exec.
+func newPipeline(exec pipelineExecFunc) *Pipeline {
+	return &Pipeline{exec: exec}
+}
+
+// Get Queue retrieving an item by key.
+func (p *Pipeline) Get(key string) *ResultFuture {
+	f := &ResultFuture{}
+	p.queue(&pipelineOp{cmd: pipelineGet, key: key, future: f})
+	return f
+}
+
+// Set Queue storing an item for a given number of expire.
+func (p *Pipeline) Set(key string, value interface{}, expire time.Duration) *StatusFuture {
+	f := &StatusFuture{}
+	p.queue(&pipelineOp{cmd: pipelineSet, key: key, value: value, expire: expire, future: f})
+	return f
+}
+
+// Add Queue storing an item if the key does not exist.
+func (p *Pipeline) Add(key string, value interface{}, expire time.Duration) *BoolFuture {
+	f := &BoolFuture{}
+	p.queue(&pipelineOp{cmd: pipelineAdd, key: key, value: value, expire: expire, future: f})
+	return f
+}
+
+// Increment Queue incrementing the value of an item.
+func (p *Pipeline) Increment(key string, value int64) *IntFuture {
+	f := &IntFuture{}
+	p.queue(&pipelineOp{cmd: pipelineIncrement, key: key, value: value, future: f})
+	return f
+}
+
+// Expire Queue setting expiration time for a key.
+func (p *Pipeline) Expire(key string, expire time.Duration) *BoolFuture {
+	f := &BoolFuture{}
+	p.queue(&pipelineOp{cmd: pipelineExpire, key: key, expire: expire, future: f})
+	return f
+}
+
+// Forget Queue removing an item.
+func (p *Pipeline) Forget(key string) *StatusFuture {
+	f := &StatusFuture{}
+	p.queue(&pipelineOp{cmd: pipelineForget, key: key, future: f})
+	return f
+}
+
+// Len Get the number of the queued operations.
+func (p *Pipeline) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.ops)
+}
+
+// Exec Run all the queued operations and empty the queue. The futures are resolved even if an operation fails,
+// and the first error other than cache.Nil is returned.
+func (p *Pipeline) Exec(ctx context.Context) error {
+	p.mu.Lock()
+	ops := p.ops
+	p.ops = nil
+	p.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if err := p.exec(ctx, ops); err != nil {
+		return abortPipeline(ops, err)
+	}
+
+	for _, op := range ops {
+		if op.err != nil && op.err != Nil {
+			return op.err
+		}
+	}
+
+	return nil
+}
+
+// queue Append an operation to the queue.
+func (p *Pipeline) queue(op *pipelineOp) {
+	p.mu.Lock()
+	p.ops = append(p.ops, op)
+	p.mu.Unlock()
+}
+
+// Val Get the value of the future, the zero value if the pipeline is not executed.
+func (f *Future[T]) Val() T {
+	return f.val
+}
+
+// Err Get the error of the future, ErrNotExecuted if the pipeline is not executed.
+func (f *Future[T]) Err() error {
+	if !f.done {
+		return ErrNotExecuted
+	}
+
+	return f.err
+}
+
+// Result Get the value and the error of the future.
+func (f *Future[T]) Result() (T, error) {
+	return f.val, f.Err()
+}
+
+// resolve Set the value and the error of the future.
+func (f *Future[T]) resolve(val interface{}, err error) {
+	if v, ok := val.(T); ok {
+		f.val = v
+	}
+	f.err = err
+	f.done = true
+}
+
+// resolve Resolve the future of the operation.
+func (op *pipelineOp) resolve(val interface{}, err error) {
+	op.err = err
+	op.future.resolve(val, err)
+}
+
+// run Run the operation against a store one by one.
+func (op *pipelineOp) run(ctx context.Context, store Store) {
+	switch op.cmd {
+	case pipelineGet:
+		rst := store.Get(ctx, op.key)
+		op.resolve(rst, rst.Err())
+	case pipelineSet:
+		op.resolve(struct{}{}, store.Set(ctx, op.key, op.value, op.expire))
+	case pipelineAdd:
+		op.resolve(store.Add(ctx, op.key, op.value, op.expire))
+	case pipelineIncrement:
+		op.resolve(store.Increment(ctx, op.key, op.value.(int64)))
+	case pipelineExpire:
+		op.resolve(store.Expire(ctx, op.key, op.expire))
+	case pipelineForget:
+		op.resolve(struct{}{}, store.Forget(ctx, op.key))
+	}
+}
+
+// abortPipeline Resolve all the operations with the error aborting them.
+func abortPipeline(ops []*pipelineOp, err error) error {
+	for _, op := range ops {
+		op.resolve(nil, err)
+	}
+
+	return err
+}
+
+// runPipeline Get an exec running the operations against a store with up to the workers at once.
+// The operations on the same key are run by a single worker in the queued order, so a Get queued
+// after a Set of the key sees the value it stored.
+func runPipeline(store Store, workers int) pipelineExecFunc {
+	if workers <= 0 {
+		workers = defaultPipelineWorkers
+	}
+
+	return func(ctx context.Context, ops []*pipelineOp) error {
+		groups := groupPipeline(ops)
+
+		if workers == 1 || len(groups) == 1 {
+			for _, op := range ops {
+				op.run(ctx, store)
+			}
+			return nil
+		}
+
+		var (
+			wg    sync.WaitGroup
+			queue = make(chan []*pipelineOp)
+		)
+
+		for i := 0; i < workers && i < len(groups); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for group := range queue {
+					for _, op := range group {
+						op.run(ctx, store)
+					}
+				}
+			}()
+		}
+
+		for _, group := range groups {
+			queue <- group
+		}
+		close(queue)
+		wg.Wait()
+
+		return nil
+	}
+}
+
+// groupPipeline Group the operations by key, keeping the queued order within each group.
+func groupPipeline(ops []*pipelineOp) [][]*pipelineOp {
+	var (
+		groups  = make([][]*pipelineOp, 0, len(ops))
+		indexes = make(map[string]int, len(ops))
+	)
+
+	for _, op := range ops {
+		i, ok := indexes[op.key]
+		if !ok {
+			i = len(groups)
+			indexes[op.key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], op)
+	}
+
+	return groups
+}
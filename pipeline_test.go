@@ -0,0 +1,139 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 7:50 下午
+ * @Desc: pipeline test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+func TestCache_Pipeline(t *testing.T) {
+	redis := cache.NewRedisStore(&cache.RedisOptions{Addrs: []string{miniredis.RunT(t).Addr()}, Prefix: "cache", Keyring: newKeyring(t, "a", "a")})
+
+	caches := map[string]cache.Cache{
+		"memory":    cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":     newRedisCache(t),
+		"tagged":    newRedisCache(t).Tags("people"),
+		"encrypted": cache.NewCacheWithStore(redis),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				p   = c.Pipeline()
+			)
+
+			_ = c.Set(ctx, "old", "lucy", time.Minute)
+
+			set := p.Set("name", "fuxiao", time.Minute)
+			incr := p.Increment("counter", 2)
+			get := p.Get("name")
+			missing := p.Get("missing")
+			expire := p.Expire("name", time.Hour)
+			forget := p.Forget("old")
+
+			if err := get.Err(); err != cache.ErrNotExecuted {
+				t.Fatalf("pipeline: expected the future to be unresolved, got %v", err)
+			}
+
+			if err := p.Exec(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := set.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if val, err := incr.Result(); err != nil || val != 2 {
+				t.Fatalf("pipeline: unexpected increment %v, %v", val, err)
+			}
+
+			if rst, err := get.Result(); err != nil || rst.Val() != "fuxiao" {
+				t.Fatalf("pipeline: unexpected value %v", err)
+			}
+
+			if err := missing.Err(); err != cache.Nil {
+				t.Fatalf("pipeline: expected cache.Nil, got %v", err)
+			}
+
+			if ok, err := expire.Result(); err != nil || !ok {
+				t.Fatalf("pipeline: unexpected expire %v, %v", ok, err)
+			}
+
+			if err := forget.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.Get(ctx, "old").Err(); err != cache.Nil {
+				t.Fatalf("pipeline: expected the item to be forgotten, got %v", err)
+			}
+
+			if p.Len() != 0 {
+				t.Fatalf("pipeline: expected the queue to be emptied, got %d", p.Len())
+			}
+		})
+	}
+}
+
+func TestCache_PipelineOrder(t *testing.T) {
+	caches := map[string]cache.Cache{
+		"memory":    cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}),
+		"redis":     newRedisCache(t),
+		"memcached": cache.NewCacheWithStore(newMemcachedStore(t, &cache.MemcachedOptions{Prefix: "cache", PipelineWorkers: 4})),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var (
+				ctx  = context.Background()
+				p    = c.Pipeline()
+				gets = make([]*cache.ResultFuture, 0, 20)
+			)
+
+			_ = c.Set(ctx, "name", "lucy", time.Minute)
+
+			for i := 0; i < 20; i++ {
+				p.Set("name", strconv.Itoa(i), time.Minute)
+				p.Set("other"+strconv.Itoa(i), i, time.Minute)
+				gets = append(gets, p.Get("name"))
+			}
+
+			taken := p.Add("name", "fuxiao", time.Minute)
+			added := p.Add("fresh", "fuxiao", time.Minute)
+
+			if err := p.Exec(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, get := range gets {
+				if val, err := get.Val().Result(); err != nil || val != strconv.Itoa(i) {
+					t.Fatalf("pipeline: expected %d, got %v, %v", i, val, err)
+				}
+			}
+
+			if ok, err := taken.Result(); err != nil || ok {
+				t.Fatalf("pipeline: expected the existing item to be kept, got %v, %v", ok, err)
+			}
+
+			if ok, err := added.Result(); err != nil || !ok {
+				t.Fatalf("pipeline: expected the missing item to be added, got %v, %v", ok, err)
+			}
+
+			if val, err := c.Get(ctx, "name").Result(); err != nil || val != "19" {
+				t.Fatalf("pipeline: unexpected value %v, %v", val, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 9:10 下午
+ * @Desc: a rate limiter built on the cache
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+type RateLimitAlgorithm int
+
+const (
+	// FixedWindow Count the attempts in a window starting at the first attempt, reset when the window ends.
+	FixedWindow RateLimitAlgorithm = iota
+	// SlidingWindow Weight the attempts of the previous window by its overlap with a window sliding to now.
+	SlidingWindow
+	// GCRA Spread the attempts evenly over the decay with a burst up to the max attempts, a.k.a. token bucket.
+	GCRA
+)
+
+type (
+	RateLimiter struct {
+		cache   Cache
+		limiter rateLimiter
+	}
+
+	rateLimiter interface {
+		// take Record an attempt if it is allowed or forced, report the state after the attempt.
+		take(ctx context.Context, key string, maxAttempts int64, decay time.Duration, force bool) (*rateLimitState, error)
+		// peek Report the state without recording an attempt.
+		peek(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (*rateLimitState, error)
+		// clear Reset the attempts.
+		clear(ctx context.Context, key string) error
+	}
+
+	rateLimitState struct {
+		allowed    bool
+		attempts   int64
+		remaining  int64
+		retryAfter time.Duration
+	}
+)
+
+// NewRateLimiter Create a rate limiter instance over a cache, the fixed window algorithm is used by default.
+// The algorithms run as atomic scripts on redis and on top of the Add and Increment primitives on other stores.
+func NewRateLimiter(c Cache, algorithm ...RateLimitAlgorithm) *RateLimiter {
+	alg := FixedWindow
+	if len(algorithm) > 0 {
+		alg = algorithm[0]
+	}
+
+	l := &RateLimiter{cache: c}
+
+	if client, ok := c.GetClient().(Redis); ok {
+		l.limiter = &redisRateLimiter{cache: c, client: client, algorithm: alg}
+	} else {
+		l.limiter = &cacheRateLimiter{cache: c, algorithm: alg}
+	}
+
+	return l
+}
+
+// Attempt Run the callback if the key has not been accessed too many times, and record the attempt.
+// Report whether the callback was run.
+func (l *RateLimiter) Attempt(ctx context.Context, key string, maxAttempts int64, decay time.Duration, fn func() error) (bool, error) {
+	state, err := l.take(ctx, key, maxAttempts, decay, false)
+	if err != nil || !state.allowed {
+		return false, err
+	}
+
+	return true, fn()
+}
+
+// TooManyAttempts Determine if the key has been accessed too many times.
+func (l *RateLimiter) TooManyAttempts(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (bool, error) {
+	state, err := l.peek(ctx, key, maxAttempts, decay)
+	if err != nil {
+		return false, err
+	}
+
+	return !state.allowed, nil
+}
+
+// Hit Record an attempt for the key regardless of the limit, return the number of attempts.
+func (l *RateLimiter) Hit(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (int64, error) {
+	state, err := l.take(ctx, key, maxAttempts, decay, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.attempts, nil
+}
+
+// Attempts Get the number of attempts for the key.
+func (l *RateLimiter) Attempts(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (int64, error) {
+	state, err := l.peek(ctx, key, maxAttempts, decay)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.attempts, nil
+}
+
+// RemainingAttempts Get the number of retries left for the key.
+func (l *RateLimiter) RemainingAttempts(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (int64, error) {
+	state, err := l.peek(ctx, key, maxAttempts, decay)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.remaining, nil
+}
+
+// AvailableIn Get the duration until the key is accessible again, zero if it is accessible now.
+func (l *RateLimiter) AvailableIn(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (time.Duration, error) {
+	state, err := l.peek(ctx, key, maxAttempts, decay)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.retryAfter, nil
+}
+
+// Clear Clear the attempts for the key.
+func (l *RateLimiter) Clear(ctx context.Context, key string) error {
+	return l.limiter.clear(ctx, key)
+}
+
+// take Validate the limit, then record an attempt if it is allowed or forced.
+func (l *RateLimiter) take(ctx context.Context, key string, maxAttempts int64, decay time.Duration, force bool) (*rateLimitState, error) {
+	if err := validateRateLimit(maxAttempts, decay); err != nil {
+		return nil, err
+	}
+
+	return l.limiter.take(ctx, key, maxAttempts, decay, force)
+}
+
+// peek Validate the limit, then report the state without recording an attempt.
+func (l *RateLimiter) peek(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (*rateLimitState, error) {
+	if err := validateRateLimit(maxAttempts, decay); err != nil {
+		return nil, err
+	}
+
+	return l.limiter.peek(ctx, key, maxAttempts, decay)
+}
+
+type cacheRateLimiter struct {
+	cache     Cache
+	algorithm RateLimitAlgorithm
+}
+
+// take Record an attempt if it is allowed or forced, report the state after the attempt.
+func (l *cacheRateLimiter) take(ctx context.Context, key string, maxAttempts int64, decay time.Duration, force bool) (*rateLimitState, error) {
+	switch l.algorithm {
+	case SlidingWindow:
+		return l.slidingWindow(ctx, key, maxAttempts, decay, true, force)
+	case GCRA:
+		return l.gcra(ctx, key, maxAttempts, decay, true, force)
+	default:
+		return l.fixedWindow(ctx, key, maxAttempts, decay, true, force)
+	}
+}
+
+// peek Report the state without recording an attempt.
+func (l *cacheRateLimiter) peek(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (*rateLimitState, error) {
+	switch l.algorithm {
+	case SlidingWindow:
+		return l.slidingWindow(ctx, key, maxAttempts, decay, false, false)
+	case GCRA:
+		return l.gcra(ctx, key, maxAttempts, decay, false, false)
+	default:
+		return l.fixedWindow(ctx, key, maxAttempts, decay, false, false)
+	}
+}
+
+// clear Reset the attempts.
+func (l *cacheRateLimiter) clear(ctx context.Context, key string) error {
+	_, err := l.cache.ForgetMany(ctx, rateLimitKeys(key)...)
+
+	return err
+}
+
+// fixedWindow Count the attempts in a counter expiring at the end of the window,
+// the end of the window is kept in a timer item since not every store can report the ttl.
+func (l *cacheRateLimiter) fixedWindow(ctx context.Context, key string, maxAttempts int64, decay time.Duration, take, force bool) (*rateLimitState, error) {
+	var (
+		now      = nowMs(time.Now())
+		timerKey = rateLimitTimerKey(key)
+	)
+
+	attempts, err := l.counter(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := attempts < maxAttempts
+
+	if take && (allowed || force) {
+		if _, err = l.cache.Add(ctx, timerKey, now+decay.Milliseconds(), decay); err != nil {
+			return nil, err
+		}
+
+		if attempts, err = l.increment(ctx, key, decay); err != nil {
+			return nil, err
+		}
+
+		allowed = allowed && attempts <= maxAttempts
+	}
+
+	state := &rateLimitState{
+		allowed:   allowed,
+		attempts:  attempts,
+		remaining: rateLimitRemaining(maxAttempts, attempts),
+	}
+
+	if attempts >= maxAttempts {
+		if end, err := l.counter(ctx, timerKey); err != nil {
+			return nil, err
+		} else if end > now {
+			state.retryAfter = time.Duration(end-now) * time.Millisecond
+		}
+	}
+
+	return state, nil
+}
+
+// slidingWindow Count the attempts of the current and the previous window in two counters alternating by window,
+// each counter expires at the end of the window after its own.
+func (l *cacheRateLimiter) slidingWindow(ctx context.Context, key string, maxAttempts int64, decay time.Duration, take, force bool) (*rateLimitState, error) {
+	var (
+		now     = nowMs(time.Now())
+		size    = decay.Milliseconds()
+		window  = now / size
+		elapsed = now - window*size
+		curKey  = rateLimitWindowKey(key, window)
+		prevKey = rateLimitWindowKey(key, window-1)
+	)
+
+	cur, err := l.counter(ctx, curKey)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := l.counter(ctx, prevKey)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := slidingAttempts(cur, prev, elapsed, size)
+	allowed := attempts < maxAttempts
+
+	if take && (allowed || force) {
+		if cur, err = l.increment(ctx, curKey, time.Duration((window+2)*size-now)*time.Millisecond); err != nil {
+			return nil, err
+		}
+
+		attempts = slidingAttempts(cur, prev, elapsed, size)
+		allowed = allowed && attempts <= maxAttempts
+	}
+
+	return &rateLimitState{
+		allowed:    allowed,
+		attempts:   attempts,
+		remaining:  rateLimitRemaining(maxAttempts, attempts),
+		retryAfter: slidingRetryAfter(cur, prev, elapsed, size, maxAttempts),
+	}, nil
+}
+
+// gcra Keep the theoretical arrival time of the next attempt, written by compare-and-swap so that
+// the concurrent attempts are retried instead of overwriting each other.
+func (l *cacheRateLimiter) gcra(ctx context.Context, key string, maxAttempts int64, decay time.Duration, take, force bool) (*rateLimitState, error) {
+	for i := 0; i < maxUpdateRetries; i++ {
+		rst, version := l.cache.GetWithVersion(ctx, key)
+
+		var (
+			now = float64(nowMs(time.Now()))
+			tat = now
+		)
+
+		if val, err := rst.Float64(); err == nil && val > now {
+			tat = val
+		} else if err != nil && err != Nil {
+			return nil, err
+		}
+
+		state, tat, store := gcraUpdate(tat, now, maxAttempts, decay, take, force)
+		if !store {
+			return state, nil
+		}
+
+		ok, err := l.cache.CompareAndSwap(ctx, key, version, strconv.FormatFloat(tat, 'f', 3, 64), time.Duration(math.Ceil(tat-now))*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return state, nil
+		}
+	}
+
+	return nil, ErrCASConflict
+}
+
+// counter Get the value of a counter, zero if missing.
+func (l *cacheRateLimiter) counter(ctx context.Context, key string) (int64, error) {
+	val, err := l.cache.Get(ctx, key).Int64()
+	if err == Nil {
+		return 0, nil
+	}
+
+	return val, err
+}
+
+// increment Increment a counter, setting the expiration when the counter is created.
+func (l *cacheRateLimiter) increment(ctx context.Context, key string, expire time.Duration) (int64, error) {
+	if ok, err := l.cache.Add(ctx, key, 1, expire); err != nil {
+		return 0, err
+	} else if ok {
+		return 1, nil
+	}
+
+	val, err := l.cache.Increment(ctx, key, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	// the counter expired between Add and Increment and was recreated without an expiration
+	if val == 1 {
+		if _, err = l.cache.Expire(ctx, key, expire); err != nil {
+			return 0, err
+		}
+	}
+
+	return val, nil
+}
+
+// gcraUpdate Apply an attempt to the theoretical arrival time, report the state and whether the time needs storing.
+func gcraUpdate(tat, now float64, maxAttempts int64, decay time.Duration, take, force bool) (*rateLimitState, float64, bool) {
+	var (
+		period   = float64(decay.Milliseconds())
+		interval = period / float64(maxAttempts)
+		allowed  = tat+interval-period <= now
+		store    = take && (allowed || force)
+	)
+
+	if store {
+		tat += interval
+	}
+
+	attempts := int64(math.Ceil((tat - now) / interval))
+
+	state := &rateLimitState{
+		allowed:   allowed,
+		attempts:  attempts,
+		remaining: rateLimitRemaining(maxAttempts, attempts),
+	}
+
+	if retry := tat + interval - period - now; retry > 0 {
+		state.retryAfter = time.Duration(math.Ceil(retry)) * time.Millisecond
+	}
+
+	return state, tat, store
+}
+
+// slidingAttempts Estimate the attempts in the window sliding to now.
+func slidingAttempts(cur, prev, elapsed, size int64) int64 {
+	return cur + prev*(size-elapsed)/size
+}
+
+// slidingRetryAfter Estimate the duration until the window sliding to now drops below the max attempts.
+func slidingRetryAfter(cur, prev, elapsed, size, maxAttempts int64) time.Duration {
+	var retry int64
+
+	switch {
+	case cur >= maxAttempts:
+		retry = size - elapsed + size*(cur-maxAttempts)/cur + 1
+	case slidingAttempts(cur, prev, elapsed, size) >= maxAttempts:
+		retry = size*(prev-maxAttempts+cur)/prev - elapsed + 1
+	}
+
+	if retry <= 0 {
+		return 0
+	}
+
+	return time.Duration(retry) * time.Millisecond
+}
+
+// validateRateLimit Check the limit, the algorithms divide by the max attempts and by the decay in milliseconds.
+func validateRateLimit(maxAttempts int64, decay time.Duration) error {
+	if maxAttempts < 1 || decay < time.Millisecond {
+		return ErrInvalidLimit
+	}
+
+	return nil
+}
+
+// rateLimitRemaining Get the number of attempts left.
+func rateLimitRemaining(maxAttempts, attempts int64) int64 {
+	if attempts >= maxAttempts {
+		return 0
+	}
+
+	return maxAttempts - attempts
+}
+
+// rateLimitTimerKey Get the key keeping the end of the fixed window.
+func rateLimitTimerKey(key string) string {
+	return fmt.Sprintf("{%s}:timer", key)
+}
+
+// rateLimitWindowKey Get the key of the counter of a sliding window, the counters alternate by window.
+func rateLimitWindowKey(key string, window int64) string {
+	return fmt.Sprintf("{%s}:%d", key, window&1)
+}
+
+// rateLimitKeys Get all keys kept for a rate limited key.
+func rateLimitKeys(key string) []string {
+	return []string{key, rateLimitTimerKey(key), rateLimitWindowKey(key, 0), rateLimitWindowKey(key, 1)}
+}
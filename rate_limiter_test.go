@@ -0,0 +1,233 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 10:05 下午
+ * @Desc: rate limiter test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	for name, algorithm := range map[string]cache.RateLimitAlgorithm{
+		"fixed window":   cache.FixedWindow,
+		"sliding window": cache.SlidingWindow,
+		"gcra":           cache.GCRA,
+	} {
+		var (
+			c       = cache.NewCacheWithStore(newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}))
+			limiter = cache.NewRateLimiter(c, algorithm)
+			calls   int
+		)
+
+		for i := 0; i < 5; i++ {
+			ok, err := limiter.Attempt(ctx, "login", 3, time.Minute, func() error {
+				calls++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != (i < 3) {
+				t.Fatalf("%s: unexpected attempt %d result %v", name, i, ok)
+			}
+		}
+
+		if calls != 3 {
+			t.Fatalf("%s: expected the callback to run 3 times, got %d", name, calls)
+		}
+
+		if ok, _ := limiter.TooManyAttempts(ctx, "login", 3, time.Minute); !ok {
+			t.Fatalf("%s: expected too many attempts", name)
+		}
+
+		if n, _ := limiter.RemainingAttempts(ctx, "login", 3, time.Minute); n != 0 {
+			t.Fatalf("%s: expected no remaining attempts, got %d", name, n)
+		}
+
+		if d, _ := limiter.AvailableIn(ctx, "login", 3, time.Minute); d <= 0 || d > time.Minute {
+			t.Fatalf("%s: unexpected available in %v", name, d)
+		}
+
+		if err := limiter.Clear(ctx, "login"); err != nil {
+			t.Fatal(err)
+		}
+
+		if n, _ := limiter.Hit(ctx, "login", 3, time.Minute); n != 1 {
+			t.Fatalf("%s: expected the attempts to be cleared, got %d", name, n)
+		}
+
+		if n, _ := limiter.RemainingAttempts(ctx, "login", 3, time.Minute); n != 2 {
+			t.Fatalf("%s: expected 2 remaining attempts, got %d", name, n)
+		}
+	}
+}
+
+func TestRateLimiter_Decay(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		c       = cache.NewCacheWithStore(newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1}))
+		limiter = cache.NewRateLimiter(c)
+	)
+
+	for i := 0; i < 2; i++ {
+		_, _ = limiter.Hit(ctx, "api", 2, 50*time.Millisecond)
+	}
+
+	if ok, _ := limiter.TooManyAttempts(ctx, "api", 2, 50*time.Millisecond); !ok {
+		t.Fatal("rate limiter: expected too many attempts")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if ok, _ := limiter.TooManyAttempts(ctx, "api", 2, 50*time.Millisecond); ok {
+		t.Fatal("rate limiter: expected the attempts to expire with the window")
+	}
+}
+
+func TestRateLimiter_MemcachedDecay(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		limiter = cache.NewRateLimiter(cache.NewCacheWithStore(newMemcachedStore(t, nil)))
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.Hit(ctx, "api", 2, 500*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ok, _ := limiter.TooManyAttempts(ctx, "api", 2, 500*time.Millisecond); !ok {
+		t.Fatal("mc: expected too many attempts")
+	}
+
+	// a sub-second decay rounds up to a second instead of never expiring
+	time.Sleep(1100 * time.Millisecond)
+
+	if ok, _ := limiter.TooManyAttempts(ctx, "api", 2, 500*time.Millisecond); ok {
+		t.Fatal("mc: expected the attempts to expire with the window")
+	}
+}
+
+func TestRateLimiter_GCRAConcurrent(t *testing.T) {
+	for driver, c := range map[string]cache.Cache{
+		"memory":    cache.NewCacheWithStore(newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})),
+		"memcached": cache.NewCacheWithStore(newMemcachedStore(t, nil)),
+	} {
+		var (
+			ctx     = context.Background()
+			limiter = cache.NewRateLimiter(c, cache.GCRA)
+			allowed int32
+			wg      sync.WaitGroup
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				ok, err := limiter.Attempt(ctx, "api", 5, time.Minute, func() error { return nil })
+				if err != nil {
+					t.Error(err)
+				}
+
+				if ok {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// the concurrent attempts are swapped in one by one, none of them overwrites another
+		if allowed != 5 {
+			t.Fatalf("gcra on %s: expected 5 attempts to be allowed, got %d", driver, allowed)
+		}
+	}
+}
+
+func TestRateLimiter_InvalidLimit(t *testing.T) {
+	ctx := context.Background()
+
+	for name, algorithm := range map[string]cache.RateLimitAlgorithm{
+		"fixed window":   cache.FixedWindow,
+		"sliding window": cache.SlidingWindow,
+		"gcra":           cache.GCRA,
+	} {
+		for driver, c := range map[string]cache.Cache{
+			"memory": cache.NewCacheWithStore(newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})),
+			"redis":  newRedisCache(t),
+		} {
+			limiter := cache.NewRateLimiter(c, algorithm)
+
+			if _, err := limiter.Hit(ctx, "api", 0, time.Minute); err != cache.ErrInvalidLimit {
+				t.Fatalf("%s on %s: expected cache.ErrInvalidLimit for no max attempts, got %v", name, driver, err)
+			}
+
+			if _, err := limiter.AvailableIn(ctx, "api", 0, time.Minute); err != cache.ErrInvalidLimit {
+				t.Fatalf("%s on %s: expected cache.ErrInvalidLimit for no max attempts, got %v", name, driver, err)
+			}
+
+			if _, err := limiter.Attempt(ctx, "api", 3, 500*time.Microsecond, func() error { return nil }); err != cache.ErrInvalidLimit {
+				t.Fatalf("%s on %s: expected cache.ErrInvalidLimit for a sub-millisecond decay, got %v", name, driver, err)
+			}
+
+			if _, err := limiter.Hit(ctx, "api", 3, time.Millisecond); err != nil {
+				t.Fatalf("%s on %s: expected a millisecond decay to be accepted, got %v", name, driver, err)
+			}
+		}
+	}
+}
+
+func TestRateLimiter_RedisServerClock(t *testing.T) {
+	var (
+		ctx = context.Background()
+		mr  = miniredis.RunT(t)
+		c   = cache.NewCache(&cache.Options{
+			Driver: cache.RedisDriver,
+			Prefix: "cache",
+			Stores: cache.Stores{Redis: &cache.RedisOptions{Addrs: []string{mr.Addr()}}},
+		})
+		now = time.Now().Add(-time.Hour).Truncate(time.Minute).Add(10 * time.Second)
+	)
+
+	// the windows and the arrival times follow the clock of the server, whatever the clock of the client
+	mr.SetTime(now)
+
+	sliding := cache.NewRateLimiter(c, cache.SlidingWindow)
+	for i := 0; i < 2; i++ {
+		_, _ = sliding.Hit(ctx, "login", 2, time.Minute)
+	}
+
+	if d, _ := sliding.AvailableIn(ctx, "login", 2, time.Minute); d != 50*time.Second+time.Millisecond {
+		t.Fatalf("sliding window: expected the retry to be computed on the server clock, got %v", d)
+	}
+
+	gcra := cache.NewRateLimiter(c, cache.GCRA)
+	for i := 0; i < 2; i++ {
+		_, _ = gcra.Hit(ctx, "api", 2, time.Minute)
+	}
+
+	if ok, _ := gcra.TooManyAttempts(ctx, "api", 2, time.Minute); !ok {
+		t.Fatal("gcra: expected too many attempts")
+	}
+
+	mr.SetTime(now.Add(time.Minute))
+
+	if ok, _ := gcra.TooManyAttempts(ctx, "api", 2, time.Minute); ok {
+		t.Fatal("gcra: expected the attempts to decay on the server clock")
+	}
+}
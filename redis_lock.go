@@ -10,30 +10,114 @@ package cache
 import (
 	"context"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
+var redisAcquireScript = redis.NewScript(`
+local ok
+if tonumber(ARGV[2]) > 0 then
+	ok = redis.call('set', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2])
+else
+	ok = redis.call('set', KEYS[1], ARGV[1], 'NX')
+end
+if ok then
+	return redis.call('incr', KEYS[2])
+end
+return 0`)
+
+var redisReleaseScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0`)
+
+var redisExtendScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0`)
+
 type RedisLock struct {
 	BaseLock
 	client Redis
 }
 
 // NewRedisLock Create a redis lock instance.
-func NewRedisLock(client Redis, name string, time time.Duration) Lock {
+func NewRedisLock(client Redis, name string, time time.Duration, owner ...string) Lock {
 	return &RedisLock{
-		BaseLock: BaseLock{
-			name: name,
-			time: time,
-		},
-		client: client,
+		BaseLock: newBaseLock(name, time, owner...),
+		client:   client,
 	}
 }
 
-// Acquire Attempt to acquire the lock.
-func (l *RedisLock) Acquire() (bool, error) {
-	return l.client.SetNX(context.Background(), l.name, 1, l.time).Result()
+// Acquire Attempt to acquire the lock, a fencing token is issued atomically along with the acquisition.
+func (l *RedisLock) Acquire(ctx context.Context) (bool, error) {
+	return l.acquire(ctx)
+}
+
+// Release Release the lock if it is still owned by the current owner.
+func (l *RedisLock) Release(ctx context.Context) (bool, error) {
+	l.unwatch()
+
+	return l.release(ctx)
+}
+
+// ForceRelease Release the lock regardless of ownership.
+func (l *RedisLock) ForceRelease(ctx context.Context) error {
+	l.unwatch()
+
+	return l.forceRelease(ctx)
+}
+
+// Get Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+func (l *RedisLock) Get(ctx context.Context, callbacks ...func() error) (bool, error) {
+	return getLock(ctx, l, callbacks)
+}
+
+// Block Attempt to acquire the lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the lock.
+func (l *RedisLock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockLock(ctx, l, l.name, wait, callbacks)
+}
+
+// Extend Refresh the lease of the lock to the given ttl if it is still owned by the current owner.
+func (l *RedisLock) Extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	ttl, err := l.extendTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := redisExtendScript.Run(ctx, l.client, []string{l.name}, l.owner, ttl.Milliseconds()).Int64()
+
+	return n == 1, err
+}
+
+// Watch Renew the lease of the lock in the background until the lock is released or the context ends.
+func (l *RedisLock) Watch(ctx context.Context) <-chan error {
+	return l.watch(ctx, l.Extend)
+}
+
+// acquire Attempt to acquire the lock with the context.
+func (l *RedisLock) acquire(ctx context.Context) (bool, error) {
+	token, err := redisAcquireScript.Run(ctx, l.client, []string{l.name, fencingKey(l.name)}, l.owner, l.time.Milliseconds()).Int64()
+	if err != nil || token == 0 {
+		return false, err
+	}
+
+	l.setToken(token)
+
+	return true, nil
+}
+
+// release Release the lock with the context if it is still owned by the current owner.
+func (l *RedisLock) release(ctx context.Context) (bool, error) {
+	n, err := redisReleaseScript.Run(ctx, l.client, []string{l.name}, l.owner).Int64()
+
+	return n == 1, err
 }
 
-// Release Release the lock.
-func (l *RedisLock) Release() error {
-	return l.client.Del(context.Background(), l.name).Err()
+// forceRelease Release the lock with the context regardless of ownership.
+func (l *RedisLock) forceRelease(ctx context.Context) error {
+	return l.client.Del(ctx, l.name).Err()
 }
@@ -0,0 +1,154 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 9:40 下午
+ * @Desc: a redis rate limiter running the algorithms as atomic scripts
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisServerNow The prelude of a script defining now, the time of the server in milliseconds, so that
+// the clocks of the clients don't matter. The commands are replicated instead of the script, which reads the time.
+const redisServerNow = `
+redis.replicate_commands()
+local clock = redis.call('time')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+`
+
+// KEYS[1] counter, ARGV max attempts, decay in ms, take, force
+var redisFixedWindowScript = redis.NewScript(`
+local max, decay = tonumber(ARGV[1]), tonumber(ARGV[2])
+local attempts = tonumber(redis.call('get', KEYS[1]) or '0')
+local allowed = attempts < max
+if ARGV[3] == '1' and (allowed or ARGV[4] == '1') then
+	attempts = redis.call('incr', KEYS[1])
+	if redis.call('pttl', KEYS[1]) < 0 then
+		redis.call('pexpire', KEYS[1], decay)
+	end
+	allowed = allowed and attempts <= max
+end
+local retry = 0
+if attempts >= max then
+	retry = math.max(redis.call('pttl', KEYS[1]), 0)
+end
+return {allowed and 1 or 0, attempts, retry}`)
+
+// KEYS[1] even window counter, KEYS[2] odd window counter, ARGV max attempts, window size in ms, take, force
+var redisSlidingWindowScript = redis.NewScript(redisServerNow + `
+local max, size = tonumber(ARGV[1]), tonumber(ARGV[2])
+local window = math.floor(now / size)
+local elapsed = now - window * size
+local cur_key, prev_key = KEYS[window % 2 + 1], KEYS[(window + 1) % 2 + 1]
+local cur = tonumber(redis.call('get', cur_key) or '0')
+local prev = tonumber(redis.call('get', prev_key) or '0')
+local attempts = cur + math.floor(prev * (size - elapsed) / size)
+local allowed = attempts < max
+if ARGV[3] == '1' and (allowed or ARGV[4] == '1') then
+	cur = redis.call('incr', cur_key)
+	if cur == 1 then
+		redis.call('pexpire', cur_key, (window + 2) * size - now)
+	end
+	attempts = cur + math.floor(prev * (size - elapsed) / size)
+	allowed = allowed and attempts <= max
+end
+local retry = 0
+if cur >= max then
+	retry = size - elapsed + math.floor(size * (cur - max) / cur) + 1
+elseif attempts >= max then
+	retry = math.floor(size * (prev - max + cur) / prev) - elapsed + 1
+end
+return {allowed and 1 or 0, attempts, math.max(retry, 0)}`)
+
+// KEYS[1] theoretical arrival time, ARGV max attempts, decay in ms, take, force
+var redisGCRAScript = redis.NewScript(redisServerNow + `
+local max, period = tonumber(ARGV[1]), tonumber(ARGV[2])
+local interval = period / max
+local tat = tonumber(redis.call('get', KEYS[1]) or '0')
+if tat < now then
+	tat = now
+end
+local allowed = tat + interval - period <= now
+if ARGV[3] == '1' and (allowed or ARGV[4] == '1') then
+	tat = tat + interval
+	redis.call('set', KEYS[1], string.format('%.3f', tat), 'PX', string.format('%d', math.ceil(tat - now)))
+end
+local retry = math.max(math.ceil(tat + interval - period - now), 0)
+return {allowed and 1 or 0, math.ceil((tat - now) / interval), retry}`)
+
+type redisRateLimiter struct {
+	cache     Cache
+	client    Redis
+	algorithm RateLimitAlgorithm
+}
+
+// take Record an attempt if it is allowed or forced, report the state after the attempt.
+func (l *redisRateLimiter) take(ctx context.Context, key string, maxAttempts int64, decay time.Duration, force bool) (*rateLimitState, error) {
+	return l.run(ctx, key, maxAttempts, decay, true, force)
+}
+
+// peek Report the state without recording an attempt.
+func (l *redisRateLimiter) peek(ctx context.Context, key string, maxAttempts int64, decay time.Duration) (*rateLimitState, error) {
+	return l.run(ctx, key, maxAttempts, decay, false, false)
+}
+
+// clear Reset the attempts.
+func (l *redisRateLimiter) clear(ctx context.Context, key string) error {
+	return l.client.Del(ctx, rateLimitKeys(l.cache.PrefixKey(key))...).Err()
+}
+
+// run Run the script of the algorithm.
+func (l *redisRateLimiter) run(ctx context.Context, key string, maxAttempts int64, decay time.Duration, take, force bool) (*rateLimitState, error) {
+	var (
+		script *redis.Script
+		keys   []string
+		args   = []interface{}{maxAttempts, decay.Milliseconds()}
+		flags  = []interface{}{redisFlag(take), redisFlag(force)}
+	)
+
+	key = l.cache.PrefixKey(key)
+
+	switch l.algorithm {
+	case SlidingWindow:
+		script = redisSlidingWindowScript
+		keys = []string{rateLimitWindowKey(key, 0), rateLimitWindowKey(key, 1)}
+	case GCRA:
+		script = redisGCRAScript
+		keys = []string{key}
+	default:
+		script = redisFixedWindowScript
+		keys = []string{key}
+	}
+
+	val, err := script.Run(ctx, l.client, keys, append(args, flags...)...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	rst := make([]int64, 3)
+	for i, v := range val.([]interface{}) {
+		rst[i], _ = v.(int64)
+	}
+
+	return &rateLimitState{
+		allowed:    rst[0] == 1,
+		attempts:   rst[1],
+		remaining:  rateLimitRemaining(maxAttempts, rst[1]),
+		retryAfter: time.Duration(rst[2]) * time.Millisecond,
+	}, nil
+}
+
+// redisFlag Convert a flag to a script argument.
+func redisFlag(flag bool) string {
+	if flag {
+		return "1"
+	}
+
+	return "0"
+}
@@ -0,0 +1,108 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 10:40 上午
+ * @Desc: a redis read-write lock instance
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var redisRLockScript = redis.NewScript(redisRefreshHolders + `
+local writer = redis.call('get', KEYS[1])
+if writer and writer ~= ARGV[3] then
+	return 0
+end
+redis.call('zremrangebyscore', KEYS[2], '-inf', now)
+redis.call('zadd', KEYS[2], score(ARGV[1], ARGV[2]), ARGV[3])
+refresh(KEYS[2], ARGV[2])
+return 1`)
+
+var redisRUnlockScript = redis.NewScript(redisRefreshHolders + `
+local n = redis.call('zrem', KEYS[2], ARGV[2])
+refresh(KEYS[2], ARGV[1])
+return n`)
+
+var redisWLockScript = redis.NewScript(redisRefreshHolders + `
+local writer = redis.call('get', KEYS[1])
+if writer and writer ~= ARGV[1] then
+	return 0
+end
+redis.call('zremrangebyscore', KEYS[2], '-inf', now)
+for _, reader in ipairs(redis.call('zrange', KEYS[2], 0, -1)) do
+	if reader ~= ARGV[1] then
+		return 0
+	end
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('set', KEYS[1], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('set', KEYS[1], ARGV[1])
+end
+return 1`)
+
+type RedisRWLock struct {
+	BaseLock
+	client Redis
+	keys   []string
+}
+
+// NewRedisRWLock Create a redis read-write lock instance, the writer is kept in a string key and
+// the readers are kept in a sorted set scored by expiration on the clock of the server, both in the same hash slot.
+func NewRedisRWLock(client Redis, name string, time time.Duration, owner ...string) RWLock {
+	return &RedisRWLock{
+		BaseLock: newBaseLock(name, time, owner...),
+		client:   client,
+		keys:     []string{fmt.Sprintf("{%s}:write", name), fmt.Sprintf("{%s}:read", name)},
+	}
+}
+
+// RLock Attempt to acquire the shared read lock.
+func (l *RedisRWLock) RLock(ctx context.Context) (bool, error) {
+	n, err := redisRLockScript.Run(ctx, l.client, l.keys,
+		l.time.Milliseconds(), lockForever, l.owner).Int64()
+
+	return n == 1, err
+}
+
+// RUnlock Release the read lock held by the current owner.
+func (l *RedisRWLock) RUnlock(ctx context.Context) (bool, error) {
+	n, err := redisRUnlockScript.Run(ctx, l.client, l.keys,
+		lockForever, l.owner).Int64()
+
+	return n == 1, err
+}
+
+// RBlock Attempt to acquire the read lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the read lock.
+func (l *RedisRWLock) RBlock(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, l.name, wait, l.RLock, l.RUnlock, callbacks)
+}
+
+// Lock Attempt to acquire the exclusive write lock.
+func (l *RedisRWLock) Lock(ctx context.Context) (bool, error) {
+	n, err := redisWLockScript.Run(ctx, l.client, l.keys,
+		l.owner, l.time.Milliseconds()).Int64()
+
+	return n == 1, err
+}
+
+// Unlock Release the write lock held by the current owner.
+func (l *RedisRWLock) Unlock(ctx context.Context) (bool, error) {
+	n, err := redisReleaseScript.Run(ctx, l.client, l.keys[:1], l.owner).Int64()
+
+	return n == 1, err
+}
+
+// Block Attempt to acquire the write lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the write lock.
+func (l *RedisRWLock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, l.name, wait, l.Lock, l.Unlock, callbacks)
+}
@@ -0,0 +1,100 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 10:05 上午
+ * @Desc: a redis semaphore instance
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisRefreshHolders Score the holders on the clock of the server read by redisServerNow, so that the holders
+// of all clients are scored against the same clock, and expire the sorted set of holders along with its last holder.
+const redisRefreshHolders = redisServerNow + `
+local function score(ttl, forever)
+	if tonumber(ttl) > 0 then
+		return now + tonumber(ttl)
+	end
+	return tonumber(forever)
+end
+local function refresh(key, forever)
+	local last = redis.call('zrange', key, -1, -1, 'WITHSCORES')
+	if #last == 0 then
+		return
+	end
+	local score = tonumber(last[2])
+	if score >= tonumber(forever) then
+		redis.call('persist', key)
+	else
+		redis.call('pexpire', key, math.max(math.ceil(score - now), 1))
+	end
+end
+`
+
+var redisSemaphoreAcquireScript = redis.NewScript(redisRefreshHolders + `
+redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+if redis.call('zscore', KEYS[1], ARGV[4]) or redis.call('zcard', KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call('zadd', KEYS[1], score(ARGV[1], ARGV[2]), ARGV[4])
+	refresh(KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+var redisSemaphoreReleaseScript = redis.NewScript(redisRefreshHolders + `
+local n = redis.call('zrem', KEYS[1], ARGV[2])
+refresh(KEYS[1], ARGV[1])
+return n`)
+
+var redisSemaphoreCountScript = redis.NewScript(redisRefreshHolders + `
+return redis.call('zcount', KEYS[1], '(' .. now, '+inf')`)
+
+type RedisSemaphore struct {
+	BaseLock
+	client  Redis
+	permits int
+}
+
+// NewRedisSemaphore Create a redis semaphore instance, which keeps its holders in a sorted set scored by
+// expiration on the clock of the server.
+func NewRedisSemaphore(client Redis, name string, permits int, time time.Duration, owner ...string) Semaphore {
+	return &RedisSemaphore{
+		BaseLock: newBaseLock(name, time, owner...),
+		client:   client,
+		permits:  permits,
+	}
+}
+
+// Acquire Attempt to acquire a permit of the semaphore.
+func (s *RedisSemaphore) Acquire(ctx context.Context) (bool, error) {
+	n, err := redisSemaphoreAcquireScript.Run(ctx, s.client, []string{s.name + semaphoreSuffix},
+		s.time.Milliseconds(), lockForever, s.permits, s.owner).Int64()
+
+	return n == 1, err
+}
+
+// Release Release the permit held by the current owner.
+func (s *RedisSemaphore) Release(ctx context.Context) (bool, error) {
+	n, err := redisSemaphoreReleaseScript.Run(ctx, s.client, []string{s.name + semaphoreSuffix},
+		lockForever, s.owner).Int64()
+
+	return n == 1, err
+}
+
+// Block Attempt to acquire a permit until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the permit.
+func (s *RedisSemaphore) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, s.name, wait, s.Acquire, s.Release, callbacks)
+}
+
+// Count Return the number of permits in use.
+func (s *RedisSemaphore) Count(ctx context.Context) (int, error) {
+	n, err := redisSemaphoreCountScript.Run(ctx, s.client, []string{s.name + semaphoreSuffix}).Int64()
+
+	return int(n), err
+}
@@ -20,7 +20,9 @@ type (
 	Redis      = redis.UniversalClient
 	RedisStore struct {
 		BaseStore
-		client Redis
+		client       Redis
+		batcher      *getBatcher
+		txMaxRetries int
 	}
 
 	RedisOptions struct {
@@ -31,9 +33,69 @@ type (
 		Prefix           string
 		DefaultNilValue  string
 		DefaultNilExpire int64
+		Codec            Codec
+		// Compressor The compressor compressing the values, the values are stored uncompressed if it's nil.
+		Compressor Compressor
+		// CompressThreshold The values shorter than the threshold in bytes are stored uncompressed, default 1024.
+		CompressThreshold int
+		// Keyring The keyring sealing the values, the values are stored in plain if it's nil.
+		// The values sealed with a rotated key are sealed with the primary key again when read.
+		Keyring *Keyring
+		// BatchWindow The window merging the concurrent Get calls into a single MGET, zero disables the batching.
+		BatchWindow time.Duration
+		// BatchSize The number of keys flushing a batch before its window ends, default 100.
+		BatchSize int
+		// TxMaxRetries The number of times a transaction is retried when a watched key changes, default 3,
+		// a negative number disables the retries.
+		TxMaxRetries int
 	}
 )
 
+// KEYS[1] key, ARGV[1] old value, ARGV[2] new value
+var redisRewriteScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+local ttl = redis.call('pttl', KEYS[1])
+if ttl > 0 then
+	redis.call('set', KEYS[1], ARGV[2], 'PX', ttl)
+else
+	redis.call('set', KEYS[1], ARGV[2])
+end
+return 1`)
+
+// KEYS[1] key, ARGV[1] revision stamp
+var redisGetWithVersionScript = redis.NewScript(`
+local value = redis.call('get', KEYS[1])
+if not value or string.byte(value) == 5 or string.match(value, '^%-?%d+$') then
+	return value
+end
+value = ARGV[1] .. value
+local ttl = redis.call('pttl', KEYS[1])
+if ttl > 0 then
+	redis.call('set', KEYS[1], value, 'PX', ttl)
+else
+	redis.call('set', KEYS[1], value)
+end
+return value`)
+
+// KEYS[1] key, ARGV[1] whether the key exists, ARGV[2] old value, ARGV[3] new value, ARGV[4] expiration in milliseconds
+var redisCompareAndSwapScript = redis.NewScript(`
+local current = redis.call('get', KEYS[1])
+if ARGV[1] == '1' then
+	if current ~= ARGV[2] then
+		return 0
+	end
+elseif current then
+	return 0
+end
+if tonumber(ARGV[4]) > 0 then
+	redis.call('set', KEYS[1], ARGV[3], 'PX', ARGV[4])
+else
+	redis.call('set', KEYS[1], ARGV[3])
+end
+return 1`)
+
 // NewRedisStore Create a redis store instance.
 func NewRedisStore(opt *RedisOptions) Store {
 	c := &RedisStore{client: redis.NewUniversalClient(&redis.UniversalOptions{
@@ -45,6 +107,20 @@ func NewRedisStore(opt *RedisOptions) Store {
 	c.SetPrefix(opt.Prefix)
 	c.SetDefaultNilValue(opt.DefaultNilValue)
 	c.SetDefaultNilExpire(opt.DefaultNilExpire)
+	c.SetCodec(opt.Codec)
+	c.SetCompressor(opt.Compressor, opt.CompressThreshold)
+	c.SetKeyring(opt.Keyring)
+
+	if opt.BatchWindow > 0 {
+		c.batcher = newGetBatcher(opt.BatchWindow, opt.BatchSize, c.GetMany)
+	}
+
+	switch {
+	case opt.TxMaxRetries > 0:
+		c.txMaxRetries = opt.TxMaxRetries
+	case opt.TxMaxRetries == 0:
+		c.txMaxRetries = defaultTxMaxRetries
+	}
 
 	return c
 }
@@ -94,8 +170,12 @@ func (c *RedisStore) HasMany(ctx context.Context, keys ...string) (map[string]bo
 	return ret, nil
 }
 
-// Get Retrieve an item from the cache by key.
+// Get Retrieve an item from the cache by key, the concurrent calls are merged into a single MGET if the batching is enabled.
 func (c *RedisStore) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	if c.batcher != nil {
+		return c.batcher.get(ctx, key, defaultValue...)
+	}
+
 	val, err := c.client.Get(ctx, c.PrefixKey(key)).Result()
 	if err == redis.Nil {
 		if len(defaultValue) > 0 {
@@ -105,7 +185,13 @@ func (c *RedisStore) Get(ctx context.Context, key string, defaultValue ...interf
 		return NewResult("", Nil)
 	}
 
-	return NewResult(val, err)
+	if err != nil {
+		return NewResult("", err)
+	}
+
+	c.resealOnRead(ctx, key, val)
+
+	return newStoredResult(c.decode(val))
 }
 
 // GetMany Retrieve multiple items from the cache by key.
@@ -130,7 +216,8 @@ func (c *RedisStore) GetMany(ctx context.Context, keys ...string) (map[string]Re
 
 	for i, v := range rst {
 		if v != nil {
-			ret[keys[i]] = NewResult(v.(string), nil)
+			c.resealOnRead(ctx, keys[i], v.(string))
+			ret[keys[i]] = newStoredResult(c.decode(v.(string)))
 		} else {
 			ret[keys[i]] = NewResult("", Nil)
 		}
@@ -139,6 +226,33 @@ func (c *RedisStore) GetMany(ctx context.Context, keys ...string) (map[string]Re
 	return ret, nil
 }
 
+// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn.
+func (c *RedisStore) GetManySet(ctx context.Context, keys []string, fn defaultValuesFunc) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = c.PrefixKey(key)
+	}
+
+	rst, err := c.client.MGet(ctx, prefixedKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	raws := make(map[string]string, len(keys))
+	for i, v := range rst {
+		if v != nil {
+			c.resealOnRead(ctx, keys[i], v.(string))
+			raws[keys[i]] = v.(string)
+		}
+	}
+
+	return c.getManySet(ctx, c, keys, raws, fn)
+}
+
 // GetSet Retrieve or set an item from the cache by key.
 func (c *RedisStore) GetSet(ctx context.Context, key string, fn defaultValueFunc) Result {
 	var (
@@ -151,7 +265,7 @@ func (c *RedisStore) GetSet(ctx context.Context, key string, fn defaultValueFunc
 			return NewResult("", err)
 		}
 
-		switch ret, err := storeSharedCallGroup.Call(prefixedKey, func() (interface{}, error) {
+		switch ret, err := c.shareCall(ctx, key, func() (interface{}, error) {
 			val, expire, err := fn()
 			return defaultValueRet{
 				val:    val,
@@ -160,8 +274,11 @@ func (c *RedisStore) GetSet(ctx context.Context, key string, fn defaultValueFunc
 		}); err {
 		case nil:
 			ret := ret.(defaultValueRet)
-			val := conv.String(ret.val)
-			return NewResult(val, nil, c.Set(ctx, key, val, ret.expire))
+			val, err := encodeValue(c.GetCodec(), ret.val)
+			if err != nil {
+				return NewResult("", err)
+			}
+			return newStoredResult(val, nil, c.Set(ctx, key, encodedValue(val), ret.expire))
 		case Nil:
 			ret := ret.(defaultValueRet)
 			expire := c.GetDefaultNilExpire()
@@ -173,169 +290,190 @@ func (c *RedisStore) GetSet(ctx context.Context, key string, fn defaultValueFunc
 			return NewResult("", err)
 		}
 	} else {
-		if val := cmd.Val(); val == c.GetDefaultNilValue() {
+		c.resealOnRead(ctx, key, cmd.Val())
+
+		if val, err := c.decode(cmd.Val()); err != nil {
+			return NewResult("", err)
+		} else if val == c.GetDefaultNilValue() {
 			return NewResult("", Nil)
 		} else {
-			return NewResult(val)
+			return newStoredResult(val)
 		}
 	}
 }
 
+// GetWithVersion Retrieve an item from the cache by key along with its version. The value is stamped with
+// a random revision kept until the item is written again, so a value written back fails the CompareAndSwap.
+// A counter is left unstamped to stay countable, it's compared by value and an increment undone is missed.
+func (c *RedisStore) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	stamp := string(revisionMarker) + randomToken()
+
+	raw, err := redisGetWithVersionScript.Run(ctx, c.client, []string{c.PrefixKey(key)}, stamp).Text()
+	switch err {
+	case nil:
+	case redis.Nil:
+		return NewResult("", Nil), Version{}
+	default:
+		return NewResult("", err), Version{}
+	}
+
+	return c.storedResult(raw), Version{exists: true, raw: raw}
+}
+
+// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+// report whether it was stored.
+func (c *RedisStore) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	exists := "0"
+	if version.exists {
+		exists = "1"
+	}
+
+	n, err := redisCompareAndSwapScript.Run(ctx, c.client, []string{c.PrefixKey(key)}, exists, version.raw, val, expire.Milliseconds()).Int64()
+
+	return n == 1, err
+}
+
 // Set Store an item in the cache for a given number of expire.
 func (c *RedisStore) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	return c.client.Set(ctx, c.PrefixKey(key), conv.String(value), expiration).Err()
+	val, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, c.PrefixKey(key), val, expiration).Err()
 }
 
 // SetMany Store multiple items in the cache for a given number of expire.
 func (c *RedisStore) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
-	var (
-		lua          = `for i,k in ipairs(KEYS) do redis.call('setex',k,ARGV[1],ARGV[i+1]) end`
-		prefixedKeys = make([]string, 0, len(values))
-		args         = make([]interface{}, 1, len(values)+1)
-	)
-
-	args[1] = expiration / time.Second
+	pipe := c.client.Pipeline()
 
 	for key, value := range values {
-		prefixedKeys = append(prefixedKeys, c.PrefixKey(key))
-		args = append(args, conv.String(value))
+		val, err := c.encode(value)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, c.PrefixKey(key), val, expiration)
 	}
-	c.client.MGet()
-	c.client.MSet()
 
-	c.client.PExpire()
+	_, err := pipe.Exec(ctx)
 
-	return c.client.Eval(ctx, lua, prefixedKeys, args...).Err()
+	return err
 }
 
 // Forever Store an item in the cache indefinitely.
 func (c *RedisStore) Forever(ctx context.Context, key string, value interface{}) error {
-	return c.client.Set(ctx, c.PrefixKey(key), value, 0).Err()
+	return c.Set(ctx, key, value, 0)
 }
 
 // ForeverMany Store multiple items in the cache indefinitely.
 func (c *RedisStore) ForeverMany(ctx context.Context, values map[string]interface{}) error {
-	var (
-		pipe = c.client.Pipeline()
-	)
-
-	for key, value := range values {
-		pipe.Set(ctx, c.PrefixKey(key), value, 0)
-	}
-
-	if _, err := pipe.Exec(ctx); err != nil {
-		return err
-	}
-
-	return nil
+	return c.SetMany(ctx, values, 0)
 }
 
 // Add Store an item in the cache if the key does not exist.
 func (c *RedisStore) Add(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
-	if expiration > 0 {
-
-	} else if expiration == redis.KeepTTL {
-
+	val, err := c.encode(value)
+	if err != nil {
+		return false, err
 	}
 
-	lua := "return redis.call('exists',KEYS[1])<1 and redis.call('setex',KEYS[1],ARGV[2],ARGV[1])"
-
-	return c.client.Eval(ctx, lua, []string{c.PrefixKey(key)}, value, expiration/time.Second).Bool()
+	return c.client.SetNX(ctx, c.PrefixKey(key), val, expiration).Result()
 }
 
 // Increment Increment the value of an item in the cache.
-func (c *RedisStore) Increment(key string, value int64) (int64, error) {
-	return c.client.IncrBy(context.Background(), c.PrefixKey(key), value).Result()
+func (c *RedisStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	return c.client.IncrBy(ctx, c.PrefixKey(key), value).Result()
 }
 
 // IncrementMany Increment the value of multiple items in the cache.
-func (c *RedisStore) IncrementMany(values map[string]int64) (map[string]int64, error) {
+func (c *RedisStore) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
 	var (
-		ctx  = context.Background()
 		pipe = c.client.Pipeline()
-		ret  = make(map[string]int64)
+		cmds = make(map[string]*redis.IntCmd, len(values))
 	)
 
 	for key, value := range values {
-		ret[key] = pipe.IncrBy(ctx, c.PrefixKey(key), value).Val()
+		cmds[key] = pipe.IncrBy(ctx, c.PrefixKey(key), value)
 	}
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, err
 	}
 
+	ret := make(map[string]int64, len(cmds))
+	for key, cmd := range cmds {
+		ret[key] = cmd.Val()
+	}
+
 	return ret, nil
 }
 
 // Decrement Decrement the value of an item in the cache.
-func (c *RedisStore) Decrement(key string, value int64) (int64, error) {
-	ret := c.client.DecrBy(context.Background(), c.PrefixKey(key), value)
-
-	return ret.Val(), ret.Err()
+func (c *RedisStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	return c.client.DecrBy(ctx, c.PrefixKey(key), value).Result()
 }
 
 // DecrementMany Decrement the value of multiple items in the cache.
-func (c *RedisStore) DecrementMany(values map[string]int64) (map[string]int64, error) {
-	var (
-		ctx  = context.Background()
-		pipe = c.client.Pipeline()
-		ret  = make(map[string]int64)
-	)
-
+func (c *RedisStore) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	negated := make(map[string]int64, len(values))
 	for key, value := range values {
-		ret[key] = pipe.DecrBy(ctx, c.PrefixKey(key), value).Val()
-	}
-
-	if _, err := pipe.Exec(ctx); err != nil {
-		return nil, err
+		negated[key] = 0 - value
 	}
 
-	return ret, nil
+	return c.IncrementMany(ctx, negated)
 }
 
 // Expire Set expiration time for a key.
-func (c *RedisStore) Expire(key string, expire time.Duration) (bool, error) {
-	return c.client.Expire(context.Background(), c.PrefixKey(key), expire).Result()
+func (c *RedisStore) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	return c.client.Expire(ctx, c.PrefixKey(key), expire).Result()
 }
 
 // ExpireMany Expire Set expiration time for multiple key.
-func (c *RedisStore) ExpireMany(values map[string]time.Duration) (map[string]bool, error) {
+func (c *RedisStore) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
 	var (
-		ctx  = context.Background()
 		pipe = c.client.Pipeline()
-		ret  = make(map[string]bool)
+		cmds = make(map[string]*redis.BoolCmd, len(values))
 	)
 
 	for key, expire := range values {
-		ret[key] = pipe.Expire(ctx, c.PrefixKey(key), expire).Val()
+		cmds[key] = pipe.Expire(ctx, c.PrefixKey(key), expire)
 	}
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, err
 	}
 
+	ret := make(map[string]bool, len(cmds))
+	for key, cmd := range cmds {
+		ret[key] = cmd.Val()
+	}
+
 	return ret, nil
 }
 
 // Forget Remove an item from the cache.
-func (c *RedisStore) Forget(key string) error {
-	return c.client.Del(context.Background(), c.PrefixKey(key)).Err()
+func (c *RedisStore) Forget(ctx context.Context, key string) error {
+	return c.client.Del(ctx, c.PrefixKey(key)).Err()
 }
 
 // ForgetMany Remove multiple items from the cache.
-func (c *RedisStore) ForgetMany(keys ...string) (int64, error) {
+func (c *RedisStore) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
+	prefixedKeys := make([]string, len(keys))
 	for i, key := range keys {
-		keys[i] = c.PrefixKey(key)
+		prefixedKeys[i] = c.PrefixKey(key)
 	}
 
-	ret := c.client.Del(context.Background(), keys...)
-
-	return ret.Val(), ret.Err()
+	return c.client.Del(ctx, prefixedKeys...).Result()
 }
 
 // Flush Remove all items from the cache.
-func (c *RedisStore) Flush() error {
-	return c.client.FlushDB(context.Background()).Err()
+func (c *RedisStore) Flush(ctx context.Context) error {
+	return c.client.FlushDB(ctx).Err()
 }
 
 // Lock Get a lock instance.
@@ -343,7 +481,133 @@ func (c *RedisStore) Lock(name string, time time.Duration) Lock {
 	return NewRedisLock(c.client, c.PrefixKey(name), time)
 }
 
+// RestoreLock Restore a lock instance using the owner token.
+func (c *RedisStore) RestoreLock(name string, owner string) Lock {
+	return NewRedisLock(c.client, c.PrefixKey(name), 0, owner)
+}
+
+// RWLock Get a read-write lock instance.
+func (c *RedisStore) RWLock(name string, time time.Duration) RWLock {
+	return NewRedisRWLock(c.client, c.PrefixKey(name), time)
+}
+
+// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+func (c *RedisStore) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return NewRedisSemaphore(c.client, c.PrefixKey(name), permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock, zero if never.
+func (c *RedisStore) FencingToken(ctx context.Context, name string) (int64, error) {
+	token, err := c.client.Get(ctx, fencingKey(c.PrefixKey(name))).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return token, err
+}
+
+// Pipeline Get a pipeline queuing mixed operations, the operations are sent in a single redis pipeline.
+func (c *RedisStore) Pipeline() *Pipeline {
+	return newPipeline(func(ctx context.Context, ops []*pipelineOp) error {
+		// the errors are carried by the commands
+		_ = c.execPipeline(ctx, c.client.Pipeline(), ops, false)
+		return nil
+	})
+}
+
 // GetClient Get the redis client instance.
 func (c *RedisStore) GetClient() interface{} {
 	return c.client
 }
+
+// Close Close the redis client.
+func (c *RedisStore) Close() error {
+	return c.client.Close()
+}
+
+// rewrite Replace the value of an item keeping its expiration if the value is unchanged.
+func (c *RedisStore) rewrite(ctx context.Context, key string, old string, new string) (bool, error) {
+	n, err := redisRewriteScript.Run(ctx, c.client, []string{c.PrefixKey(key)}, old, new).Int64()
+
+	return n == 1, err
+}
+
+// resealOnRead Seal a value read with the primary key again in place if it was sealed with a rotated key.
+func (c *RedisStore) resealOnRead(ctx context.Context, key string, val string) {
+	if sealed, ok := c.reseal(val); ok {
+		_, _ = c.rewrite(ctx, key, val, sealed)
+	}
+}
+
+// execPipeline Send the operations in a redis pipeline and resolve their futures from the replies,
+// the error of the pipeline is returned, such as redis.TxFailedErr of a transaction pipeline.
+// The pipeline isn't sent at all if it's atomic and a value fails to encode.
+func (c *RedisStore) execPipeline(ctx context.Context, pipe redis.Pipeliner, ops []*pipelineOp, atomic bool) error {
+	cmds := make([]redis.Cmder, len(ops))
+
+	for i, op := range ops {
+		key := c.PrefixKey(op.key)
+
+		switch op.cmd {
+		case pipelineGet:
+			cmds[i] = pipe.Get(ctx, key)
+		case pipelineSet:
+			val, err := c.encode(op.value)
+			if err != nil && atomic {
+				return abortPipeline(ops, err)
+			}
+			if err != nil {
+				op.resolve(nil, err)
+				continue
+			}
+			cmds[i] = pipe.Set(ctx, key, val, op.expire)
+		case pipelineAdd:
+			val, err := c.encode(op.value)
+			if err != nil && atomic {
+				return abortPipeline(ops, err)
+			}
+			if err != nil {
+				op.resolve(nil, err)
+				continue
+			}
+			cmds[i] = pipe.SetNX(ctx, key, val, op.expire)
+		case pipelineIncrement:
+			cmds[i] = pipe.IncrBy(ctx, key, op.value.(int64))
+		case pipelineExpire:
+			cmds[i] = pipe.Expire(ctx, key, op.expire)
+		case pipelineForget:
+			cmds[i] = pipe.Del(ctx, key)
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	for i, op := range ops {
+		switch cmd := cmds[i].(type) {
+		case *redis.StringCmd:
+			val, err := cmd.Result()
+			switch err {
+			case nil:
+				c.resealOnRead(ctx, op.key, val)
+				rst := newStoredResult(c.decode(val))
+				op.resolve(rst, rst.Err())
+			case redis.Nil:
+				op.resolve(NewResult("", Nil), Nil)
+			default:
+				op.resolve(NewResult("", err), err)
+			}
+		case *redis.StatusCmd:
+			op.resolve(struct{}{}, cmd.Err())
+		case *redis.IntCmd:
+			if op.cmd == pipelineForget {
+				op.resolve(struct{}{}, cmd.Err())
+			} else {
+				op.resolve(cmd.Result())
+			}
+		case *redis.BoolCmd:
+			op.resolve(cmd.Result())
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,145 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 9:10 下午
+ * @Desc: a redis transaction instance
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// defaultTxMaxRetries The default number of times a transaction is retried when a watched key changes.
+const defaultTxMaxRetries = 3
+
+type (
+	// Tx A redis transaction. The reads run at once against the watched keys, and the writes are queued
+	// and run atomically by MULTI and EXEC once the function of the transaction returns, each write
+	// returns a future holding its result after the transaction is executed.
+	Tx interface {
+		// Get Retrieve an item from the cache by key at once.
+		Get(key string) Result
+		// Set Queue storing an item for a given number of expire.
+		Set(key string, value interface{}, expire time.Duration) *StatusFuture
+		// Forever Queue storing an item indefinitely.
+		Forever(key string, value interface{}) *StatusFuture
+		// Add Queue storing an item if the key does not exist.
+		Add(key string, value interface{}, expire time.Duration) *BoolFuture
+		// Increment Queue incrementing the value of an item.
+		Increment(key string, value int64) *IntFuture
+		// Decrement Queue decrementing the value of an item.
+		Decrement(key string, value int64) *IntFuture
+		// Forget Queue removing an item.
+		Forget(key string) *StatusFuture
+		// Expire Queue setting expiration time for a key.
+		Expire(key string, expire time.Duration) *BoolFuture
+	}
+
+	redisTx struct {
+		ctx   context.Context
+		store *RedisStore
+		tx    *redis.Tx
+		ops   []*pipelineOp
+	}
+)
+
+// Transaction Run the fn in a transaction watching the keys, the queued writes are run atomically
+// only if none of the watched keys changed in the meantime. The transaction is run again from the
+// start when a watched key changes, and cache.ErrTxConflict is returned once the retries run out.
+// The keys are prefixed by the store, and must hash to the same slot on a redis cluster.
+func (c *RedisStore) Transaction(ctx context.Context, watchKeys []string, fn func(tx Tx) error) error {
+	prefixedKeys := make([]string, len(watchKeys))
+	for i, key := range watchKeys {
+		prefixedKeys[i] = c.PrefixKey(key)
+	}
+
+	for i := 0; i <= c.txMaxRetries; i++ {
+		err := c.client.Watch(ctx, func(tx *redis.Tx) error {
+			t := &redisTx{ctx: ctx, store: c, tx: tx}
+
+			if err := fn(t); err != nil {
+				return err
+			}
+
+			if len(t.ops) == 0 {
+				return nil
+			}
+
+			return c.execPipeline(ctx, tx.TxPipeline(), t.ops, true)
+		}, prefixedKeys...)
+
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return ErrTxConflict
+}
+
+// Get Retrieve an item from the cache by key at once.
+func (t *redisTx) Get(key string) Result {
+	val, err := t.tx.Get(t.ctx, t.store.PrefixKey(key)).Result()
+	switch err {
+	case nil:
+		return t.store.storedResult(val)
+	case redis.Nil:
+		return NewResult("", Nil)
+	default:
+		return NewResult("", err)
+	}
+}
+
+// Set Queue storing an item for a given number of expire.
+func (t *redisTx) Set(key string, value interface{}, expire time.Duration) *StatusFuture {
+	f := &StatusFuture{}
+	t.queue(&pipelineOp{cmd: pipelineSet, key: key, value: value, expire: expire, future: f})
+	return f
+}
+
+// Forever Queue storing an item indefinitely.
+func (t *redisTx) Forever(key string, value interface{}) *StatusFuture {
+	return t.Set(key, value, 0)
+}
+
+// Add Queue storing an item if the key does not exist.
+func (t *redisTx) Add(key string, value interface{}, expire time.Duration) *BoolFuture {
+	f := &BoolFuture{}
+	t.queue(&pipelineOp{cmd: pipelineAdd, key: key, value: value, expire: expire, future: f})
+	return f
+}
+
+// Increment Queue incrementing the value of an item.
+func (t *redisTx) Increment(key string, value int64) *IntFuture {
+	f := &IntFuture{}
+	t.queue(&pipelineOp{cmd: pipelineIncrement, key: key, value: value, future: f})
+	return f
+}
+
+// Decrement Queue decrementing the value of an item.
+func (t *redisTx) Decrement(key string, value int64) *IntFuture {
+	return t.Increment(key, -value)
+}
+
+// Forget Queue removing an item.
+func (t *redisTx) Forget(key string) *StatusFuture {
+	f := &StatusFuture{}
+	t.queue(&pipelineOp{cmd: pipelineForget, key: key, future: f})
+	return f
+}
+
+// Expire Queue setting expiration time for a key.
+func (t *redisTx) Expire(key string, expire time.Duration) *BoolFuture {
+	f := &BoolFuture{}
+	t.queue(&pipelineOp{cmd: pipelineExpire, key: key, expire: expire, future: f})
+	return f
+}
+
+// queue Append a write to the transaction.
+func (t *redisTx) queue(op *pipelineOp) {
+	t.ops = append(t.ops, op)
+}
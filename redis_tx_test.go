@@ -0,0 +1,108 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 9:30 下午
+ * @Desc: redis transaction test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/dobyte/cache"
+)
+
+func newRedisStore(t *testing.T, opt *cache.RedisOptions) *cache.RedisStore {
+	opt.Addrs = []string{miniredis.RunT(t).Addr()}
+	opt.Prefix = "cache"
+
+	return cache.NewRedisStore(opt).(*cache.RedisStore)
+}
+
+func TestRedisStore_Transaction(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		store    = newRedisStore(t, &cache.RedisOptions{})
+		attempts int
+		from, to *cache.IntFuture
+	)
+
+	_ = store.Set(ctx, "from", 10, 0)
+	_ = store.Set(ctx, "to", 0, 0)
+
+	err := store.Transaction(ctx, []string{"from", "to"}, func(tx cache.Tx) error {
+		if attempts++; attempts == 1 {
+			// a concurrent write to a watched key fails the first attempt
+			_ = store.Set(ctx, "from", 20, 0)
+		}
+
+		balance, err := tx.Get("from").Int64()
+		if err != nil {
+			return err
+		}
+
+		if balance < 5 {
+			return errors.New("insufficient balance")
+		}
+
+		from = tx.Decrement("from", 5)
+		to = tx.Increment("to", 5)
+		tx.Expire("to", time.Minute)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("tx: expected the transaction to be retried once, ran %d times", attempts)
+	}
+
+	if from.Val() != 15 || to.Val() != 5 {
+		t.Fatalf("tx: unexpected balances %d, %d", from.Val(), to.Val())
+	}
+
+	if val := store.Get(ctx, "from").Val(); val != "15" {
+		t.Fatalf("tx: expected the prefixed key to be written, got %q", val)
+	}
+}
+
+func TestRedisStore_TransactionConflict(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		store    = newRedisStore(t, &cache.RedisOptions{TxMaxRetries: 1})
+		attempts int
+	)
+
+	err := store.Transaction(ctx, []string{"name"}, func(tx cache.Tx) error {
+		attempts++
+		_ = store.Set(ctx, "name", attempts, 0)
+
+		tx.Set("name", "fuxiao", time.Minute)
+
+		return nil
+	})
+	if err != cache.ErrTxConflict || attempts != 2 {
+		t.Fatalf("tx: expected cache.ErrTxConflict after 2 attempts, got %v after %d", err, attempts)
+	}
+
+	abort := errors.New("abort")
+
+	if err = store.Transaction(ctx, nil, func(tx cache.Tx) error {
+		tx.Set("name", "fuxiao", time.Minute)
+		return abort
+	}); err != abort {
+		t.Fatalf("tx: expected the error of the fn, got %v", err)
+	}
+
+	if val := store.Get(ctx, "name").Val(); val != "2" {
+		t.Fatalf("tx: expected the aborted writes to be dropped, got %q", val)
+	}
+}
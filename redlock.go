@@ -0,0 +1,195 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 8:05 下午
+ * @Desc: a redlock instance
+ */
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	redlockDriftFactor    = 0.01
+	redlockDriftConstant  = 2 * time.Millisecond
+	redlockTimeoutFactor  = 10
+	redlockDefaultTimeout = 50 * time.Millisecond
+)
+
+type Redlock struct {
+	BaseLock
+	locks  []*RedisLock
+	quorum int
+}
+
+// NewRedlock Create a redlock instance, which acquires the lock on a majority of independent redis masters.
+func NewRedlock(clients []Redis, name string, time time.Duration, owner ...string) Lock {
+	l := &Redlock{
+		BaseLock: newBaseLock(name, time, owner...),
+		locks:    make([]*RedisLock, len(clients)),
+		quorum:   len(clients)/2 + 1,
+	}
+
+	for i, client := range clients {
+		l.locks[i] = NewRedisLock(client, name, time, l.owner).(*RedisLock)
+	}
+
+	return l
+}
+
+// Acquire Attempt to acquire the lock on a majority of the nodes within the validity time of the lock.
+// The lock is released on all nodes if it can't be acquired. The fencing token is the highest token
+// issued by the nodes, which is monotonic as long as a majority of the nodes keep their counters.
+func (l *Redlock) Acquire(ctx context.Context) (bool, error) {
+	var (
+		start = time.Now()
+		mu    sync.Mutex
+		token int64
+	)
+
+	n, err := l.each(ctx, func(ctx context.Context, lock *RedisLock) (bool, error) {
+		ok, err := lock.acquire(ctx)
+		if ok {
+			mu.Lock()
+			if t := lock.Token(); t > token {
+				token = t
+			}
+			mu.Unlock()
+		}
+
+		return ok, err
+	})
+
+	if n >= l.quorum && l.validity(start) > 0 {
+		l.setToken(token)
+		return true, nil
+	}
+
+	_, _ = l.each(context.WithoutCancel(ctx), func(ctx context.Context, lock *RedisLock) (bool, error) {
+		return lock.release(ctx)
+	})
+
+	return false, err
+}
+
+// Release Release the lock on all nodes if it is still owned by the current owner,
+// report whether the lock was released on a majority of the nodes.
+func (l *Redlock) Release(ctx context.Context) (bool, error) {
+	l.unwatch()
+
+	n, err := l.each(ctx, func(ctx context.Context, lock *RedisLock) (bool, error) {
+		return lock.release(ctx)
+	})
+
+	if n >= l.quorum {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// ForceRelease Release the lock on all nodes regardless of ownership.
+func (l *Redlock) ForceRelease(ctx context.Context) error {
+	l.unwatch()
+
+	_, err := l.each(ctx, func(ctx context.Context, lock *RedisLock) (bool, error) {
+		return true, lock.forceRelease(ctx)
+	})
+
+	return err
+}
+
+// Get Attempt to acquire the lock, if acquired, run the callbacks and then release the lock.
+func (l *Redlock) Get(ctx context.Context, callbacks ...func() error) (bool, error) {
+	return getLock(ctx, l, callbacks)
+}
+
+// Block Attempt to acquire the lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the lock.
+func (l *Redlock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockLock(ctx, l, l.name, wait, callbacks)
+}
+
+// Extend Refresh the lease of the lock on all nodes, report whether it was refreshed on a majority of the nodes
+// within the new validity time.
+func (l *Redlock) Extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	start := time.Now()
+
+	lease, err := l.extendTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := l.each(ctx, func(ctx context.Context, lock *RedisLock) (bool, error) {
+		return lock.Extend(ctx, lease)
+	})
+
+	if n >= l.quorum && lease-time.Since(start)-l.drift(lease) > 0 {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// Watch Renew the lease of the lock in the background until the lock is released or the context ends.
+func (l *Redlock) Watch(ctx context.Context) <-chan error {
+	return l.watch(ctx, l.Extend)
+}
+
+// each Run the function on all nodes concurrently with a per node timeout,
+// return the number of successful nodes and the first error.
+func (l *Redlock) each(ctx context.Context, fn func(ctx context.Context, lock *RedisLock) (bool, error)) (int, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		n       int
+		err     error
+		timeout = l.time / redlockTimeoutFactor
+	)
+
+	if timeout <= 0 {
+		timeout = redlockDefaultTimeout
+	}
+
+	wg.Add(len(l.locks))
+
+	for _, lock := range l.locks {
+		go func(lock *RedisLock) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			ok, e := fn(ctx, lock)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if e != nil {
+				if err == nil {
+					err = e
+				}
+			} else if ok {
+				n++
+			}
+		}(lock)
+	}
+
+	wg.Wait()
+
+	return n, err
+}
+
+// validity Return the remaining validity time of the lock acquired at the start time.
+func (l *Redlock) validity(start time.Time) time.Duration {
+	return l.time - time.Since(start) - l.drift(l.time)
+}
+
+// drift Return the clock drift allowed for the lease.
+func (l *Redlock) drift(lease time.Duration) time.Duration {
+	return time.Duration(float64(lease)*redlockDriftFactor) + redlockDriftConstant
+}
@@ -0,0 +1,95 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 8:40 下午
+ * @Desc: redlock test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/dobyte/cache"
+)
+
+func newRedisNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []cache.Redis) {
+	var (
+		servers = make([]*miniredis.Miniredis, n)
+		clients = make([]cache.Redis, n)
+	)
+
+	for i := 0; i < n; i++ {
+		servers[i] = miniredis.RunT(t)
+		clients[i] = redis.NewClient(&redis.Options{Addr: servers[i].Addr()})
+	}
+
+	return servers, clients
+}
+
+func TestRedlock_Acquire(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		servers, clients = newRedisNodes(t, 3)
+	)
+
+	lock := cache.NewRedlock(clients, "job", time.Second)
+	if ok, err := lock.Acquire(ctx); err != nil || !ok {
+		t.Fatalf("redlock: expected to acquire the lock, got %v, %v", ok, err)
+	}
+
+	for _, server := range servers {
+		if val, _ := server.Get("job"); val != lock.Owner() {
+			t.Fatalf("redlock: expected the owner token on every node, got %q", val)
+		}
+	}
+
+	if ok, _ := cache.NewRedlock(clients, "job", time.Second).Acquire(ctx); ok {
+		t.Fatal("redlock: expected the lock to be held")
+	}
+
+	if ok, _ := lock.Extend(ctx, time.Minute); !ok {
+		t.Fatal("redlock: expected the lock to be extended")
+	}
+
+	if ok, _ := lock.Release(ctx); !ok {
+		t.Fatal("redlock: expected the lock to be released")
+	}
+
+	for _, server := range servers {
+		if server.Exists("job") {
+			t.Fatal("redlock: expected the lock to be released on every node")
+		}
+	}
+}
+
+func TestRedlock_Quorum(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		servers, clients = newRedisNodes(t, 3)
+	)
+
+	servers[0].Close()
+
+	lock := cache.NewRedlock(clients, "job", time.Second)
+	if ok, _ := lock.Acquire(ctx); !ok {
+		t.Fatal("redlock: expected to acquire the lock with a node down")
+	}
+
+	_, _ = lock.Release(ctx)
+
+	_ = servers[1].Set("job", "other")
+
+	if ok, _ := lock.Acquire(ctx); ok {
+		t.Fatal("redlock: expected not to acquire the lock without a majority")
+	}
+
+	if val, _ := servers[2].Get("job"); val != "" {
+		t.Fatalf("redlock: expected the partial lock to be released, got %q", val)
+	}
+}
@@ -47,11 +47,34 @@ type result struct {
 	err      error
 	writeErr error
 	val      string
+	meta     *valueMeta
+	plain    bool
+	encoded  string
 }
 
+// NewResult Create a result of a plain value.
 func NewResult(val string, errs ...error) Result {
+	r := newResult(errs)
+	r.val, r.plain = val, true
+	
+	return r
+}
+
+// newStoredResult Create a result of a value read from a store, which may carry metadata, an escaped plain value
+// or a payload of a codec. The value is kept as read so that it can be stored again as is.
+func newStoredResult(val string, errs ...error) Result {
+	r := newResult(errs)
+	r.encoded = val
+	
+	val, r.meta, _ = parseMeta(val)
+	r.val, r.plain = unescapeValue(val)
+	
+	return r
+}
+
+// newResult Create a result carrying the read error and the write error.
+func newResult(errs []error) *result {
 	r := new(result)
-	r.val = val
 	
 	if len(errs) > 0 {
 		r.err = errs[0]
@@ -64,6 +87,16 @@ func NewResult(val string, errs ...error) Result {
 	return r
 }
 
+// storedValue Get the value of a result to store it again, the value read from a store is stored as is
+// along with its metadata and its codec payload.
+func storedValue(rst Result) interface{} {
+	if r, ok := rst.(*result); ok && r.encoded != "" {
+		return encodedValue(r.encoded)
+	}
+	
+	return rst.Val()
+}
+
 // Err Return a error from result.
 func (r *result) Err() error {
 	return r.err
@@ -158,5 +191,9 @@ func (r *result) Scan(val interface{}) error {
 		return r.err
 	}
 	
-	return conv.Scan([]byte(r.Val()), val)
+	if r.plain {
+		return conv.Scan([]byte(r.Val()), val)
+	}
+	
+	return scanValue(r.Val(), val)
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 9:10 上午
+ * @Desc: read-write lock interface define
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// rwLockSuffix The suffix of the key keeping the holders, so that it never collides with a lock of the same name.
+const rwLockSuffix = ":rw"
+
+type RWLock interface {
+	// RLock Attempt to acquire the shared read lock.
+	RLock(ctx context.Context) (bool, error)
+	// RUnlock Release the read lock held by the current owner.
+	RUnlock(ctx context.Context) (bool, error)
+	// RBlock Attempt to acquire the read lock until the wait runs out or the context ends,
+	// if acquired, run the callbacks and then release the read lock.
+	RBlock(ctx context.Context, wait time.Duration, callbacks ...func() error) error
+	// Lock Attempt to acquire the exclusive write lock.
+	Lock(ctx context.Context) (bool, error)
+	// Unlock Release the write lock held by the current owner.
+	Unlock(ctx context.Context) (bool, error)
+	// Block Attempt to acquire the write lock until the wait runs out or the context ends,
+	// if acquired, run the callbacks and then release the write lock.
+	Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error
+	// Owner Return the owner token of the lock.
+	Owner() string
+}
+
+// casRWLock A read-write lock storing its holders as a single value updated with compare-and-swap.
+type casRWLock struct {
+	BaseLock
+	update updateFunc
+}
+
+func newCASRWLock(update updateFunc, name string, time time.Duration, owner ...string) RWLock {
+	return &casRWLock{
+		BaseLock: newBaseLock(name, time, owner...),
+		update:   update,
+	}
+}
+
+// RLock Attempt to acquire the shared read lock.
+func (l *casRWLock) RLock(ctx context.Context) (bool, error) {
+	return l.modify(ctx, func(st *lockState, now time.Time) bool {
+		if st.Writer != "" && st.Writer != l.owner {
+			return false
+		}
+
+		st.Holders[l.owner] = lockExpireAt(now, l.time)
+
+		return true
+	})
+}
+
+// RUnlock Release the read lock held by the current owner.
+func (l *casRWLock) RUnlock(ctx context.Context) (bool, error) {
+	return l.modify(ctx, func(st *lockState, now time.Time) bool {
+		if _, ok := st.Holders[l.owner]; !ok {
+			return false
+		}
+
+		delete(st.Holders, l.owner)
+
+		return true
+	})
+}
+
+// RBlock Attempt to acquire the read lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the read lock.
+func (l *casRWLock) RBlock(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, l.name, wait, l.RLock, l.RUnlock, callbacks)
+}
+
+// Lock Attempt to acquire the exclusive write lock.
+func (l *casRWLock) Lock(ctx context.Context) (bool, error) {
+	return l.modify(ctx, func(st *lockState, now time.Time) bool {
+		if st.Writer != "" && st.Writer != l.owner {
+			return false
+		}
+
+		for owner := range st.Holders {
+			if owner != l.owner {
+				return false
+			}
+		}
+
+		st.Writer, st.WriterExpireAt = l.owner, lockExpireAt(now, l.time)
+
+		return true
+	})
+}
+
+// Unlock Release the write lock held by the current owner.
+func (l *casRWLock) Unlock(ctx context.Context) (bool, error) {
+	return l.modify(ctx, func(st *lockState, now time.Time) bool {
+		if st.Writer != l.owner {
+			return false
+		}
+
+		st.Writer, st.WriterExpireAt = "", 0
+
+		return true
+	})
+}
+
+// Block Attempt to acquire the write lock until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the write lock.
+func (l *casRWLock) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, l.name, wait, l.Lock, l.Unlock, callbacks)
+}
+
+// modify Update the lock state with the fn, which reports whether the state was changed.
+func (l *casRWLock) modify(ctx context.Context, fn func(st *lockState, now time.Time) bool) (bool, error) {
+	var changed bool
+
+	err := l.update(ctx, l.name+rwLockSuffix, func(value []byte) ([]byte, time.Duration, error) {
+		st, err := decodeLockState(value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		now := time.Now()
+		st.prune(now)
+
+		if changed = fn(st, now); !changed {
+			return nil, 0, errNoUpdate
+		}
+
+		return st.encode(now)
+	})
+
+	return changed, err
+}
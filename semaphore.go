@@ -0,0 +1,122 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 9:30 上午
+ * @Desc: semaphore interface define
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// semaphoreSuffix The suffix of the key keeping the holders, so that it never collides with a lock of the same name.
+const semaphoreSuffix = ":sem"
+
+type Semaphore interface {
+	// Acquire Attempt to acquire a permit of the semaphore.
+	Acquire(ctx context.Context) (bool, error)
+	// Release Release the permit held by the current owner.
+	Release(ctx context.Context) (bool, error)
+	// Block Attempt to acquire a permit until the wait runs out or the context ends,
+	// if acquired, run the callbacks and then release the permit.
+	Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error
+	// Count Return the number of permits in use.
+	Count(ctx context.Context) (int, error)
+	// Owner Return the owner token of the semaphore.
+	Owner() string
+}
+
+// casSemaphore A semaphore storing its holders as a single value updated with compare-and-swap.
+type casSemaphore struct {
+	BaseLock
+	permits int
+	update  updateFunc
+}
+
+func newCASSemaphore(update updateFunc, name string, permits int, time time.Duration, owner ...string) Semaphore {
+	return &casSemaphore{
+		BaseLock: newBaseLock(name, time, owner...),
+		permits:  permits,
+		update:   update,
+	}
+}
+
+// Acquire Attempt to acquire a permit of the semaphore.
+func (s *casSemaphore) Acquire(ctx context.Context) (bool, error) {
+	var acquired bool
+
+	err := s.update(ctx, s.name+semaphoreSuffix, func(value []byte) ([]byte, time.Duration, error) {
+		st, err := decodeLockState(value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		now := time.Now()
+		st.prune(now)
+
+		if _, ok := st.Holders[s.owner]; !ok && len(st.Holders) >= s.permits {
+			acquired = false
+			return nil, 0, errNoUpdate
+		}
+
+		acquired = true
+		st.Holders[s.owner] = lockExpireAt(now, s.time)
+
+		return st.encode(now)
+	})
+
+	return acquired, err
+}
+
+// Release Release the permit held by the current owner.
+func (s *casSemaphore) Release(ctx context.Context) (bool, error) {
+	var released bool
+
+	err := s.update(ctx, s.name+semaphoreSuffix, func(value []byte) ([]byte, time.Duration, error) {
+		st, err := decodeLockState(value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		now := time.Now()
+		st.prune(now)
+
+		if _, released = st.Holders[s.owner]; !released {
+			return nil, 0, errNoUpdate
+		}
+
+		delete(st.Holders, s.owner)
+
+		return st.encode(now)
+	})
+
+	return released, err
+}
+
+// Block Attempt to acquire a permit until the wait runs out or the context ends,
+// if acquired, run the callbacks and then release the permit.
+func (s *casSemaphore) Block(ctx context.Context, wait time.Duration, callbacks ...func() error) error {
+	return blockRun(ctx, s.name, wait, s.Acquire, s.Release, callbacks)
+}
+
+// Count Return the number of permits in use.
+func (s *casSemaphore) Count(ctx context.Context) (int, error) {
+	var count int
+
+	err := s.update(ctx, s.name+semaphoreSuffix, func(value []byte) ([]byte, time.Duration, error) {
+		st, err := decodeLockState(value)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		st.prune(time.Now())
+		count = len(st.Holders)
+
+		return nil, 0, errNoUpdate
+	})
+
+	return count, err
+}
@@ -9,6 +9,8 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -20,11 +22,11 @@ const (
 	defaultNilExpire = 10 * time.Second
 )
 
-var storeSharedCallGroup = sync.NewSharedCallGroup()
-
 type (
 	defaultValueFunc = func() (interface{}, time.Duration, error)
-	defaultValueRet  = struct {
+	// defaultValuesFunc Load the items of the missing keys, the keys left out of the returned items are cached as nil.
+	defaultValuesFunc = func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error)
+	defaultValueRet   = struct {
 		val    interface{}
 		expire time.Duration
 	}
@@ -41,6 +43,13 @@ type Store interface {
 	GetMany(ctx context.Context, keys ...string) (map[string]Result, error)
 	// GetSet Retrieve or set an item from the cache by key.
 	GetSet(ctx context.Context, key string, fn defaultValueFunc) Result
+	// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn.
+	GetManySet(ctx context.Context, keys []string, fn defaultValuesFunc) (map[string]Result, error)
+	// GetWithVersion Retrieve an item from the cache by key along with its version.
+	GetWithVersion(ctx context.Context, key string) (Result, Version)
+	// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+	// report whether it was stored.
+	CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error)
 	// Set Store an item in the cache.
 	Set(ctx context.Context, key string, value interface{}, expire time.Duration) error
 	// SetMany Store multiple items in the cache for a given number of expire.
@@ -71,26 +80,47 @@ type Store interface {
 	Flush(ctx context.Context) error
 	// Lock Get a lock instance.
 	Lock(name string, time time.Duration) Lock
+	// RestoreLock Restore a lock instance using the owner token.
+	RestoreLock(name string, owner string) Lock
+	// RWLock Get a read-write lock instance.
+	RWLock(name string, time time.Duration) RWLock
+	// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+	Semaphore(name string, permits int, time time.Duration) Semaphore
+	// FencingToken Get the latest fencing token issued for a lock, zero if never, or since its counter was evicted.
+	FencingToken(ctx context.Context, name string) (int64, error)
+	// Pipeline Get a pipeline queuing mixed operations to run them all at once.
+	Pipeline() *Pipeline
 	// PrefixKey Add prefix to the front of key.
 	PrefixKey(key string) string
 	// GetClient Get a client instance.
 	GetClient() interface{}
+	// Close Release the resources held by the store, such as the connections and the background goroutines.
+	Close() error
 }
 
 type BaseStore struct {
-	prefix           string
-	defaultNilValue  string
-	defaultNilExpire time.Duration
+	prefix            string
+	defaultNilValue   string
+	defaultNilExpire  time.Duration
+	codec             Codec
+	compressor        Compressor
+	compressThreshold int
+	keyring           *Keyring
+	group             *sync.SharedCallGroup
 }
 
+// SharedCallStats The statistics of the calls shared by the concurrent callers of a key.
+type SharedCallStats = sync.Stats
+
 // GetPrefix Get the cache key prefix.
 func (s *BaseStore) GetPrefix() string {
 	return s.prefix
 }
 
-// SetPrefix Set the cache key prefix.
+// SetPrefix Set the cache key prefix, along with the shared call group namespaced by the prefix.
 func (s *BaseStore) SetPrefix(prefix string) {
 	s.prefix = prefix
+	s.group = sync.NewSharedCallGroup(prefix)
 }
 
 // GetDefaultNilValue Get the cache default empty value.
@@ -121,6 +151,183 @@ func (s *BaseStore) SetDefaultNilExpire(expire int64) {
 	}
 }
 
+// GetCodec Get the codec encoding the values.
+func (s *BaseStore) GetCodec() Codec {
+	return s.codec
+}
+
+// SetCodec Set the codec encoding the values, the values are converted to plain strings if it's nil.
+func (s *BaseStore) SetCodec(codec Codec) {
+	s.codec = codec
+}
+
+// GetCompressor Get the compressor compressing the values.
+func (s *BaseStore) GetCompressor() Compressor {
+	return s.compressor
+}
+
+// SetCompressor Set the compressor compressing the values not shorter than the threshold in bytes,
+// the values are stored uncompressed if it's nil.
+func (s *BaseStore) SetCompressor(compressor Compressor, threshold int) {
+	s.compressor = compressor
+
+	if threshold <= 0 {
+		s.compressThreshold = defaultCompressThreshold
+	} else {
+		s.compressThreshold = threshold
+	}
+}
+
+// GetKeyring Get the keyring sealing the values.
+func (s *BaseStore) GetKeyring() *Keyring {
+	return s.keyring
+}
+
+// SetKeyring Set the keyring sealing the values, the values are stored in plain if it's nil.
+func (s *BaseStore) SetKeyring(keyring *Keyring) {
+	s.keyring = keyring
+}
+
+// SharedCallStats Get the statistics of the loaders shared by the concurrent callers of GetSet.
+func (s *BaseStore) SharedCallStats() SharedCallStats {
+	if s.group == nil {
+		return SharedCallStats{}
+	}
+
+	return s.group.Stats()
+}
+
+// shareCall Run the fn once for the concurrent callers of a key, each caller may abandon the wait through its context.
+func (s *BaseStore) shareCall(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	if s.group == nil {
+		return fn()
+	}
+
+	return s.group.Call(ctx, key, fn)
+}
+
+// getManySet Decode the raw values read by a store for the keys, load the missing items by a single call of the fn,
+// and store them along with the nil values of the keys the fn leaves out. The write errors are carried by the results.
+func (s *BaseStore) getManySet(ctx context.Context, store Store, keys []string, raws map[string]string, fn defaultValuesFunc) (map[string]Result, error) {
+	var (
+		ret     = make(map[string]Result, len(keys))
+		missing = make([]string, 0, len(keys))
+	)
+
+	for _, key := range keys {
+		raw, ok := raws[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		if val, err := s.decode(raw); err != nil {
+			ret[key] = NewResult("", err)
+		} else if val == s.GetDefaultNilValue() {
+			ret[key] = NewResult("", Nil)
+		} else {
+			ret[key] = newStoredResult(val)
+		}
+	}
+
+	if len(missing) == 0 {
+		return ret, nil
+	}
+
+	loaded, expire, err := fn(ctx, missing)
+	if err != nil && err != Nil {
+		return nil, err
+	}
+
+	var (
+		values = make(map[string]interface{}, len(loaded))
+		nils   = make(map[string]interface{}, len(missing))
+	)
+
+	for _, key := range missing {
+		if value, ok := loaded[key]; ok && value != nil {
+			val, err := encodeValue(s.codec, value)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = encodedValue(val)
+		} else {
+			nils[key] = s.GetDefaultNilValue()
+		}
+	}
+
+	var writeErr, nilWriteErr error
+
+	if len(values) > 0 {
+		writeErr = store.SetMany(ctx, values, expire)
+	}
+
+	if len(nils) > 0 {
+		nilWriteErr = store.SetMany(ctx, nils, s.GetDefaultNilExpire())
+	}
+
+	for key, val := range values {
+		ret[key] = newStoredResult(string(val.(encodedValue)), nil, writeErr)
+	}
+
+	for key := range nils {
+		ret[key] = NewResult("", Nil, nilWriteErr)
+	}
+
+	return ret, nil
+}
+
+// storedResult Decode a raw value read from the store, the cached nil value is reported as cache.Nil once decoded
+// since it's sealed and compressed along with the other values.
+func (s *BaseStore) storedResult(raw string) Result {
+	val, err := s.decode(raw)
+	if err != nil {
+		return NewResult("", err)
+	}
+
+	if val == s.GetDefaultNilValue() {
+		return NewResult("", Nil)
+	}
+
+	return newStoredResult(val)
+}
+
+// encode Encode a value into the string stored in the cache.
+// The integers are not sealed, so that the counters seeded by Add can still be incremented by the stores.
+func (s *BaseStore) encode(value interface{}) (string, error) {
+	val, err := encodeValue(s.codec, value)
+	if err != nil {
+		return "", err
+	}
+
+	if val, err = compressValue(s.compressor, s.compressThreshold, val); err != nil || s.keyring == nil || isInteger(value) {
+		return val, err
+	}
+
+	return s.keyring.seal(val)
+}
+
+// decode Decode the string stored in the cache, a *DecryptError is returned if it can't be opened.
+// The sealed values are passed through if there is no keyring, and the values not sealed are read as is.
+func (s *BaseStore) decode(val string) (string, error) {
+	val = unstampValue(val)
+
+	if s.keyring != nil {
+		var err error
+		if val, err = s.keyring.open(val); err != nil {
+			return "", err
+		}
+	}
+
+	return decompressValue(val)
+}
+
+// reseal Seal the string stored in the cache with the primary key again if it was sealed with a rotated key,
+// report whether it was resealed.
+func (s *BaseStore) reseal(val string) (string, bool) {
+	return s.keyring.reseal(unstampValue(val))
+}
+
 // PrefixKey Add prefix to the front of key.
 func (s *BaseStore) PrefixKey(key string) string {
 	if s.prefix == "" {
@@ -129,3 +336,11 @@ func (s *BaseStore) PrefixKey(key string) string {
 		return fmt.Sprintf("%s:%s", s.prefix, key)
 	}
 }
+
+// randomToken Generate a random token.
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
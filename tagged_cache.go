@@ -0,0 +1,515 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 4:02 下午
+ * @Desc: a tagged cache instance
+ */
+
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type taggedCache struct {
+	store Store
+	names []string
+}
+
+// newTaggedCache Create a tagged cache instance, the items are namespaced by the version tokens of the tags.
+func newTaggedCache(store Store, names []string) Cache {
+	return &taggedCache{
+		store: store,
+		names: names,
+	}
+}
+
+// Has Determine if an item exists in the cache.
+func (c *taggedCache) Has(ctx context.Context, key string) (bool, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	return c.store.Has(ctx, taggedKey)
+}
+
+// HasMany Determine if multiple item exists in the cache.
+func (c *taggedCache) HasMany(ctx context.Context, keys ...string) (map[string]bool, error) {
+	taggedKeys, err := c.taggedKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	rst, err := c.store.HasMany(ctx, taggedKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		ret[key] = rst[taggedKeys[i]]
+	}
+
+	return ret, nil
+}
+
+// Get Retrieve an item from the cache by key.
+func (c *taggedCache) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return NewResult("", err)
+	}
+
+	return c.store.Get(ctx, taggedKey, defaultValue...)
+}
+
+// GetMany Retrieve multiple items from the cache by key.
+func (c *taggedCache) GetMany(ctx context.Context, keys ...string) (map[string]Result, error) {
+	taggedKeys, err := c.taggedKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	rst, err := c.store.GetMany(ctx, taggedKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]Result, len(keys))
+	for i, key := range keys {
+		if r, ok := rst[taggedKeys[i]]; ok {
+			ret[key] = r
+		} else {
+			ret[key] = NewResult("", Nil)
+		}
+	}
+
+	return ret, nil
+}
+
+// GetSet Retrieve or set an item from the cache by key.
+func (c *taggedCache) GetSet(ctx context.Context, key string, fn func() (interface{}, time.Duration, error), opt ...*GetSetOptions) Result {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return NewResult("", err)
+	}
+
+	return getSet(ctx, c.store, nil, taggedKey, fn, opt...)
+}
+
+// GetManySet Retrieve multiple items from the cache by key, the missing items are loaded by a single call of the fn
+// and stored for the returned expiration, and the keys the fn leaves out are cached as nil.
+func (c *taggedCache) GetManySet(ctx context.Context, keys []string, fn func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error)) (map[string]Result, error) {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		taggedKeys[i] = namespace + key
+	}
+
+	rst, err := c.store.GetManySet(ctx, taggedKeys, func(ctx context.Context, missing []string) (map[string]interface{}, time.Duration, error) {
+		keys := make([]string, len(missing))
+		for i, taggedKey := range missing {
+			keys[i] = strings.TrimPrefix(taggedKey, namespace)
+		}
+
+		values, expire, err := fn(ctx, keys)
+
+		taggedValues := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			taggedValues[namespace+key] = value
+		}
+
+		return taggedValues, expire, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]Result, len(keys))
+	for i, key := range keys {
+		if r, ok := rst[taggedKeys[i]]; ok {
+			ret[key] = r
+		} else {
+			ret[key] = NewResult("", Nil)
+		}
+	}
+
+	return ret, nil
+}
+
+// Flexible Retrieve an item from the cache by key, the item older than the fresh is served until the stale
+// while a single process refreshes it in the background, and the missing item is loaded like GetSet.
+func (c *taggedCache) Flexible(ctx context.Context, key string, fresh, stale time.Duration, fn FlexibleFunc) Result {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return NewResult("", err)
+	}
+
+	return flexible(ctx, c.store, nil, taggedKey, fresh, stale, fn)
+}
+
+// GetWithVersion Retrieve an item from the cache by key along with its opaque version.
+func (c *taggedCache) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return NewResult("", err), Version{}
+	}
+
+	return c.store.GetWithVersion(ctx, taggedKey)
+}
+
+// CompareAndSwap Store an item in the cache if it hasn't changed since the version was read,
+// report whether it was stored.
+func (c *taggedCache) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	return c.store.CompareAndSwap(ctx, taggedKey, version, value, expire)
+}
+
+// Update Atomically replace an item with the value computed from its current value,
+// retrying on conflict up to 16 times before returning cache.ErrCASConflict.
+func (c *taggedCache) Update(ctx context.Context, key string, expire time.Duration, fn UpdateFunc) error {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return updateItem(ctx, c.store, taggedKey, expire, fn)
+}
+
+// Set Store an item in the cache.
+func (c *taggedCache) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return c.store.Set(ctx, taggedKey, value, expire)
+}
+
+// SetMany Store multiple items in the cache for a given number of expire.
+func (c *taggedCache) SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return err
+	}
+
+	taggedValues := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		taggedValues[namespace+key] = value
+	}
+
+	return c.store.SetMany(ctx, taggedValues, expire)
+}
+
+// Forever Store an item in the cache indefinitely.
+func (c *taggedCache) Forever(ctx context.Context, key string, value interface{}) error {
+	return c.Set(ctx, key, value, 0)
+}
+
+// ForeverMany Store multiple items in the cache indefinitely.
+func (c *taggedCache) ForeverMany(ctx context.Context, values map[string]interface{}) error {
+	return c.SetMany(ctx, values, 0)
+}
+
+// Add Store an item in the cache if the key does not exist.
+func (c *taggedCache) Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	return c.store.Add(ctx, taggedKey, value, expire)
+}
+
+// Increment Increment the value of an item in the cache.
+func (c *taggedCache) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.store.Increment(ctx, taggedKey, value)
+}
+
+// IncrementMany Increment the value of multiple items in the cache.
+func (c *taggedCache) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedValues := make(map[string]int64, len(values))
+	for key, value := range values {
+		taggedValues[namespace+key] = value
+	}
+
+	rst, err := c.store.IncrementMany(ctx, taggedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]int64, len(values))
+	for key := range values {
+		ret[key] = rst[namespace+key]
+	}
+
+	return ret, nil
+}
+
+// Decrement Decrement the value of an item in the cache.
+func (c *taggedCache) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.store.Decrement(ctx, taggedKey, value)
+}
+
+// DecrementMany Decrement the value of multiple items in the cache.
+func (c *taggedCache) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	negated := make(map[string]int64, len(values))
+	for key, value := range values {
+		negated[key] = 0 - value
+	}
+
+	return c.IncrementMany(ctx, negated)
+}
+
+// Forget Remove an item from the cache.
+func (c *taggedCache) Forget(ctx context.Context, key string) error {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return c.store.Forget(ctx, taggedKey)
+}
+
+// ForgetMany Remove multiple items from the cache.
+func (c *taggedCache) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
+	taggedKeys, err := c.taggedKeys(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.store.ForgetMany(ctx, taggedKeys...)
+}
+
+// Expire Set expiration time for a key.
+func (c *taggedCache) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	return c.store.Expire(ctx, taggedKey, expire)
+}
+
+// ExpireMany Set expiration time for multiple key.
+func (c *taggedCache) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedValues := make(map[string]time.Duration, len(values))
+	for key, expire := range values {
+		taggedValues[namespace+key] = expire
+	}
+
+	rst, err := c.store.ExpireMany(ctx, taggedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]bool, len(values))
+	for key := range values {
+		ret[key] = rst[namespace+key]
+	}
+
+	return ret, nil
+}
+
+// Flush Remove all items of the tags from the cache by resetting the version tokens of the tags.
+func (c *taggedCache) Flush(ctx context.Context) error {
+	for _, name := range c.names {
+		if err := c.store.Forever(ctx, tagKey(name), randomToken()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lock Get a lock instance.
+func (c *taggedCache) Lock(name string, time time.Duration) Lock {
+	return c.store.Lock(name, time)
+}
+
+// RestoreLock Restore a lock instance using the owner token.
+func (c *taggedCache) RestoreLock(name string, owner string) Lock {
+	return c.store.RestoreLock(name, owner)
+}
+
+// RWLock Get a read-write lock instance.
+func (c *taggedCache) RWLock(name string, time time.Duration) RWLock {
+	return c.store.RWLock(name, time)
+}
+
+// Semaphore Get a semaphore instance allowing up to the permits of holders at once.
+func (c *taggedCache) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return c.store.Semaphore(name, permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock, zero if never.
+func (c *taggedCache) FencingToken(ctx context.Context, name string) (int64, error) {
+	return c.store.FencingToken(ctx, name)
+}
+
+// CheckFencingToken Determine if a fencing token is still the latest one issued for a lock.
+func (c *taggedCache) CheckFencingToken(ctx context.Context, name string, token int64) (bool, error) {
+	return checkFencingToken(ctx, c.store, name, token)
+}
+
+// Funnel Get a funnel instance limiting the jobs running at the same moment, the slots are not tagged as the locks.
+func (c *taggedCache) Funnel(name string) *Funnel {
+	return newFunnel(NewCacheWithStore(c.store), name)
+}
+
+// Pipeline Get a pipeline queuing mixed operations to run them all at once, the keys are namespaced by the tags.
+func (c *taggedCache) Pipeline() *Pipeline {
+	return newPipeline(func(ctx context.Context, ops []*pipelineOp) error {
+		namespace, err := c.namespace(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, op := range ops {
+			op.key = namespace + op.key
+		}
+
+		return c.store.Pipeline().exec(ctx, ops)
+	})
+}
+
+// PrefixKey Add prefix and the namespace of the tags to the front of key.
+func (c *taggedCache) PrefixKey(key string) string {
+	if taggedKey, err := c.taggedKey(context.Background(), key); err == nil {
+		key = taggedKey
+	}
+
+	return c.store.PrefixKey(key)
+}
+
+// TaggedKey Add prefix and the namespace of the tags to the front of key, the error of reading the namespace is returned.
+func (c *taggedCache) TaggedKey(ctx context.Context, key string) (string, error) {
+	taggedKey, err := c.taggedKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return c.store.PrefixKey(taggedKey), nil
+}
+
+// GetClient Get a client instance.
+func (c *taggedCache) GetClient() interface{} {
+	return c.store.GetClient()
+}
+
+// Close Do nothing, the underlying store is shared with the untagged cache which closes it.
+func (c *taggedCache) Close() error {
+	return nil
+}
+
+// Tags Begin executing a new tags operation with the additional tags.
+func (c *taggedCache) Tags(names ...string) Cache {
+	merged := make([]string, 0, len(c.names)+len(names))
+	merged = append(merged, c.names...)
+	merged = append(merged, names...)
+
+	return newTaggedCache(c.store, merged)
+}
+
+// taggedKey Add the namespace of the tags to the front of key.
+func (c *taggedCache) taggedKey(ctx context.Context, key string) (string, error) {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return namespace + key, nil
+}
+
+// taggedKeys Add the namespace of the tags to the front of multiple keys.
+func (c *taggedCache) taggedKeys(ctx context.Context, keys []string) ([]string, error) {
+	namespace, err := c.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		taggedKeys[i] = namespace + key
+	}
+
+	return taggedKeys, nil
+}
+
+// namespace Get the namespace built from the version tokens of the tags, initializing the missing tokens.
+func (c *taggedCache) namespace(ctx context.Context) (string, error) {
+	if len(c.names) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, len(c.names))
+	for i, name := range c.names {
+		keys[i] = tagKey(name)
+	}
+
+	rst, err := c.store.GetMany(ctx, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	tokens := make([]string, len(keys))
+	for i, key := range keys {
+		if r, ok := rst[key]; ok && r.Err() == nil {
+			tokens[i] = r.Val()
+			continue
+		}
+
+		token := randomToken()
+		if ok, err := c.store.Add(ctx, key, token, 0); err != nil {
+			return "", err
+		} else if !ok {
+			if token, err = c.store.Get(ctx, key).Result(); err != nil {
+				return "", err
+			}
+		}
+		tokens[i] = token
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(tokens, "|")))
+
+	return hex.EncodeToString(sum[:]) + ":", nil
+}
+
+// tagKey Get the key of the version token of a tag.
+func tagKey(name string) string {
+	return fmt.Sprintf("tag:%s:key", name)
+}
@@ -0,0 +1,115 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 4:45 下午
+ * @Desc: tagged cache test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dobyte/cache"
+)
+
+func TestCache_Tags(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testCacheTags(t, cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"}))
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		testCacheTags(t, newRedisCache(t))
+	})
+}
+
+func testCacheTags(t *testing.T, c cache.Cache) {
+	ctx := context.Background()
+
+	if err := c.Tags("users").Set(ctx, "fuxiao", "admin", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Tags("posts").Set(ctx, "hello", "world", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Set(ctx, "name", "fuxiao", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if val := c.Tags("users").Get(ctx, "fuxiao").Val(); val != "admin" {
+		t.Fatalf("tags: unexpected value %q", val)
+	}
+
+	if err := c.Get(ctx, "fuxiao").Err(); err != cache.Nil {
+		t.Fatalf("tags: expected the tagged item to be invisible without tags, got %v", err)
+	}
+
+	if err := c.Tags("users").Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Tags("users").Get(ctx, "fuxiao").Err(); err != cache.Nil {
+		t.Fatalf("tags: expected the flushed item to be missing, got %v", err)
+	}
+
+	if val := c.Tags("posts").Get(ctx, "hello").Val(); val != "world" {
+		t.Fatalf("tags: expected the item of other tags to survive, got %q", val)
+	}
+
+	if val := c.Get(ctx, "name").Val(); val != "fuxiao" {
+		t.Fatalf("tags: expected the untagged item to survive, got %q", val)
+	}
+}
+
+func TestCache_TaggedKey(t *testing.T) {
+	var (
+		ctx = context.Background()
+		mr  = miniredis.RunT(t)
+		c   = cache.NewCache(&cache.Options{
+			Driver: cache.RedisDriver,
+			Prefix: "cache",
+			Stores: cache.Stores{Redis: &cache.RedisOptions{Addrs: []string{mr.Addr()}}},
+		})
+	)
+
+	key, err := c.Tags("users").TaggedKey(ctx, "fuxiao")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, "cache") || !strings.HasSuffix(key, "fuxiao") || key == "cache:fuxiao" {
+		t.Fatalf("tags: unexpected tagged key %q", key)
+	}
+
+	if again, err := c.Tags("users").TaggedKey(ctx, "fuxiao"); err != nil || again != key {
+		t.Fatalf("tags: expected a stable tagged key, got %q, %v", again, err)
+	}
+
+	mr.Close()
+
+	if key, err = c.Tags("users").TaggedKey(ctx, "fuxiao"); err == nil {
+		t.Fatalf("tags: expected the error of the store to be surfaced, got %q", key)
+	}
+}
+
+func TestCache_TagsClose(t *testing.T) {
+	var (
+		ctx = context.Background()
+		c   = newRedisCache(t)
+	)
+
+	if err := c.Tags("users").Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tagged view shares the store of the cache, closing it leaves the cache open
+	if err := c.Set(ctx, "name", "fuxiao", time.Minute); err != nil {
+		t.Fatalf("tags: expected the cache to stay open, got %v", err)
+	}
+}
@@ -0,0 +1,492 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 2:05 下午
+ * @Desc: a tiered store instance
+ */
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dobyte/cache/internal/conv"
+)
+
+const defaultL1Expire = time.Minute
+
+type (
+	TieredStore struct {
+		l1       Store
+		l2       Store
+		l1Expire time.Duration
+	}
+
+	TieredOptions struct {
+		// L1Expire The maximum expiration of the items stored in the first tier, default one minute.
+		L1Expire time.Duration
+	}
+)
+
+// NewTieredStore Create a tiered store instance, the l1 store is usually a memory store in front of the remote l2 store.
+func NewTieredStore(l1, l2 Store, opt *TieredOptions) Store {
+	c := &TieredStore{
+		l1:       l1,
+		l2:       l2,
+		l1Expire: defaultL1Expire,
+	}
+
+	if opt != nil && opt.L1Expire > 0 {
+		c.l1Expire = opt.L1Expire
+	}
+
+	return c
+}
+
+// Has Determine if an item exists in the cache.
+func (c *TieredStore) Has(ctx context.Context, key string) (bool, error) {
+	if ok, err := c.l1.Has(ctx, key); err == nil && ok {
+		return true, nil
+	}
+
+	return c.l2.Has(ctx, key)
+}
+
+// HasMany Determine if multiple item exists in the cache.
+func (c *TieredStore) HasMany(ctx context.Context, keys ...string) (map[string]bool, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ret, err := c.l1.HasMany(ctx, keys...)
+	if err != nil {
+		ret = make(map[string]bool, len(keys))
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !ret[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 0 {
+		return ret, nil
+	}
+
+	rst, err := c.l2.HasMany(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, ok := range rst {
+		ret[key] = ok
+	}
+
+	return ret, nil
+}
+
+// Get Retrieve an item from the cache by key.
+func (c *TieredStore) Get(ctx context.Context, key string, defaultValue ...interface{}) Result {
+	if rst := c.l1.Get(ctx, key); rst.Err() == nil {
+		return rst
+	}
+
+	rst := c.l2.Get(ctx, key)
+	switch err := rst.Err(); err {
+	case nil:
+		_ = c.l1.Set(ctx, key, storedValue(rst), c.l1Expire)
+	case Nil:
+		if len(defaultValue) > 0 {
+			return NewResult(conv.String(defaultValue[0]))
+		}
+	}
+
+	return rst
+}
+
+// GetMany Retrieve multiple items from the cache by key.
+func (c *TieredStore) GetMany(ctx context.Context, keys ...string) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ret, err := c.l1.GetMany(ctx, keys...)
+	if err != nil {
+		ret = make(map[string]Result, len(keys))
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if rst, ok := ret[key]; !ok || rst.Err() != nil {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 0 {
+		return ret, nil
+	}
+
+	rst, err := c.l2.GetMany(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(rst))
+	for key, r := range rst {
+		ret[key] = r
+		if r.Err() == nil {
+			values[key] = storedValue(r)
+		}
+	}
+
+	if len(values) > 0 {
+		_ = c.l1.SetMany(ctx, values, c.l1Expire)
+	}
+
+	return ret, nil
+}
+
+// GetSet Retrieve or set an item from the cache by key, the fn is only called when both tiers miss.
+func (c *TieredStore) GetSet(ctx context.Context, key string, fn defaultValueFunc) Result {
+	if rst := c.l1.Get(ctx, key); rst.Err() == nil {
+		return rst
+	}
+
+	rst := c.l2.GetSet(ctx, key, fn)
+	if rst.Err() == nil {
+		_ = c.l1.Set(ctx, key, storedValue(rst), c.l1Expire)
+	}
+
+	return rst
+}
+
+// GetManySet Retrieve multiple items from the cache by key, the items missing from both tiers are loaded
+// by a single call of the fn.
+func (c *TieredStore) GetManySet(ctx context.Context, keys []string, fn defaultValuesFunc) (map[string]Result, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ret, err := c.l1.GetMany(ctx, keys...)
+	if err != nil {
+		ret = make(map[string]Result, len(keys))
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if rst, ok := ret[key]; !ok || rst.Err() != nil {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 0 {
+		return ret, nil
+	}
+
+	rst, err := c.l2.GetManySet(ctx, missing, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(rst))
+	for key, r := range rst {
+		ret[key] = r
+		if r.Err() == nil {
+			values[key] = storedValue(r)
+		}
+	}
+
+	if len(values) > 0 {
+		_ = c.l1.SetMany(ctx, values, c.l1Expire)
+	}
+
+	return ret, nil
+}
+
+// GetWithVersion Retrieve an item from the second tier by key along with its version.
+func (c *TieredStore) GetWithVersion(ctx context.Context, key string) (Result, Version) {
+	return c.l2.GetWithVersion(ctx, key)
+}
+
+// CompareAndSwap Store an item in the second tier if it hasn't changed since the version was read,
+// and store it in the first tier once swapped, report whether it was stored.
+func (c *TieredStore) CompareAndSwap(ctx context.Context, key string, version Version, value interface{}, expire time.Duration) (bool, error) {
+	ok, err := c.l2.CompareAndSwap(ctx, key, version, value, expire)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	return true, c.l1.Set(ctx, key, value, c.tierExpire(expire))
+}
+
+// Set Store an item in the cache.
+func (c *TieredStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, expire); err != nil {
+		_ = c.l1.Forget(ctx, key)
+		return err
+	}
+
+	return c.l1.Set(ctx, key, value, c.tierExpire(expire))
+}
+
+// SetMany Store multiple items in the cache for a given number of expire.
+func (c *TieredStore) SetMany(ctx context.Context, values map[string]interface{}, expire time.Duration) error {
+	if err := c.l2.SetMany(ctx, values, expire); err != nil {
+		_, _ = c.l1.ForgetMany(ctx, keysOf(values)...)
+		return err
+	}
+
+	return c.l1.SetMany(ctx, values, c.tierExpire(expire))
+}
+
+// Forever Store an item in the cache indefinitely.
+func (c *TieredStore) Forever(ctx context.Context, key string, value interface{}) error {
+	return c.Set(ctx, key, value, 0)
+}
+
+// ForeverMany Store multiple items in the cache indefinitely.
+func (c *TieredStore) ForeverMany(ctx context.Context, values map[string]interface{}) error {
+	return c.SetMany(ctx, values, 0)
+}
+
+// Add Store an item in the cache if the key does not exist.
+func (c *TieredStore) Add(ctx context.Context, key string, value interface{}, expire time.Duration) (bool, error) {
+	ok, err := c.l2.Add(ctx, key, value, expire)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	return true, c.l1.Set(ctx, key, value, c.tierExpire(expire))
+}
+
+// Increment Increment the value of an item in the cache.
+func (c *TieredStore) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	newValue, err := c.l2.Increment(ctx, key, value)
+	if err != nil {
+		_ = c.l1.Forget(ctx, key)
+		return 0, err
+	}
+
+	return newValue, c.l1.Set(ctx, key, newValue, c.l1Expire)
+}
+
+// IncrementMany Increment the value of multiple items in the cache.
+func (c *TieredStore) IncrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	ret, err := c.l2.IncrementMany(ctx, values)
+
+	return ret, c.syncCounters(ctx, values, ret, err)
+}
+
+// Decrement Decrement the value of an item in the cache.
+func (c *TieredStore) Decrement(ctx context.Context, key string, value int64) (int64, error) {
+	newValue, err := c.l2.Decrement(ctx, key, value)
+	if err != nil {
+		_ = c.l1.Forget(ctx, key)
+		return 0, err
+	}
+
+	return newValue, c.l1.Set(ctx, key, newValue, c.l1Expire)
+}
+
+// DecrementMany Decrement the value of multiple items in the cache.
+func (c *TieredStore) DecrementMany(ctx context.Context, values map[string]int64) (map[string]int64, error) {
+	ret, err := c.l2.DecrementMany(ctx, values)
+
+	return ret, c.syncCounters(ctx, values, ret, err)
+}
+
+// Forget Remove an item from the cache.
+func (c *TieredStore) Forget(ctx context.Context, key string) error {
+	err := c.l2.Forget(ctx, key)
+
+	if e := c.l1.Forget(ctx, key); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// ForgetMany Remove multiple items from the cache.
+func (c *TieredStore) ForgetMany(ctx context.Context, keys ...string) (int64, error) {
+	l1Keys := make([]string, len(keys))
+	copy(l1Keys, keys)
+
+	count, err := c.l2.ForgetMany(ctx, keys...)
+
+	if _, e := c.l1.ForgetMany(ctx, l1Keys...); err == nil {
+		err = e
+	}
+
+	return count, err
+}
+
+// Expire Set expiration time for a key.
+func (c *TieredStore) Expire(ctx context.Context, key string, expire time.Duration) (bool, error) {
+	ok, err := c.l2.Expire(ctx, key, expire)
+	if err != nil || !ok || expire <= 0 {
+		_ = c.l1.Forget(ctx, key)
+		return ok, err
+	}
+
+	_, err = c.l1.Expire(ctx, key, c.tierExpire(expire))
+
+	return true, err
+}
+
+// ExpireMany Set expiration time for multiple key.
+func (c *TieredStore) ExpireMany(ctx context.Context, values map[string]time.Duration) (map[string]bool, error) {
+	ret, err := c.l2.ExpireMany(ctx, values)
+	if err != nil {
+		_, _ = c.l1.ForgetMany(ctx, keysOf(values)...)
+		return ret, err
+	}
+
+	var (
+		forgets = make([]string, 0)
+		expires = make(map[string]time.Duration)
+	)
+
+	for key, expire := range values {
+		if !ret[key] || expire <= 0 {
+			forgets = append(forgets, key)
+		} else {
+			expires[key] = c.tierExpire(expire)
+		}
+	}
+
+	if len(forgets) > 0 {
+		if _, err = c.l1.ForgetMany(ctx, forgets...); err != nil {
+			return ret, err
+		}
+	}
+
+	if len(expires) > 0 {
+		if _, err = c.l1.ExpireMany(ctx, expires); err != nil {
+			return ret, err
+		}
+	}
+
+	return ret, nil
+}
+
+// Flush Remove all items from the cache.
+func (c *TieredStore) Flush(ctx context.Context) error {
+	err := c.l2.Flush(ctx)
+
+	if e := c.l1.Flush(ctx); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// Lock Get a lock instance of the second tier, so that it is shared across processes.
+func (c *TieredStore) Lock(name string, time time.Duration) Lock {
+	return c.l2.Lock(name, time)
+}
+
+// RestoreLock Restore a lock instance of the second tier using the owner token.
+func (c *TieredStore) RestoreLock(name string, owner string) Lock {
+	return c.l2.RestoreLock(name, owner)
+}
+
+// RWLock Get a read-write lock instance of the second tier.
+func (c *TieredStore) RWLock(name string, time time.Duration) RWLock {
+	return c.l2.RWLock(name, time)
+}
+
+// Semaphore Get a semaphore instance of the second tier allowing up to the permits of holders at once.
+func (c *TieredStore) Semaphore(name string, permits int, time time.Duration) Semaphore {
+	return c.l2.Semaphore(name, permits, time)
+}
+
+// FencingToken Get the latest fencing token issued for a lock of the second tier, zero if never.
+func (c *TieredStore) FencingToken(ctx context.Context, name string) (int64, error) {
+	return c.l2.FencingToken(ctx, name)
+}
+
+// Pipeline Get a pipeline queuing mixed operations, the operations are run one by one through both tiers.
+func (c *TieredStore) Pipeline() *Pipeline {
+	return newPipeline(runPipeline(c, 1))
+}
+
+// PrefixKey Add prefix of the second tier to the front of key.
+func (c *TieredStore) PrefixKey(key string) string {
+	return c.l2.PrefixKey(key)
+}
+
+// GetClient Get the client instance of the second tier.
+func (c *TieredStore) GetClient() interface{} {
+	return c.l2.GetClient()
+}
+
+// Close Release the resources held by both tiers.
+func (c *TieredStore) Close() error {
+	err := c.l1.Close()
+	if e := c.l2.Close(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// L1 Get the first tier store.
+func (c *TieredStore) L1() Store {
+	return c.l1
+}
+
+// L2 Get the second tier store.
+func (c *TieredStore) L2() Store {
+	return c.l2
+}
+
+// tierExpire Return the expiration used in the first tier, which never exceeds the l1 expire.
+func (c *TieredStore) tierExpire(expire time.Duration) time.Duration {
+	if expire <= 0 || expire > c.l1Expire {
+		return c.l1Expire
+	}
+
+	return expire
+}
+
+// syncCounters Write the counters changed in the second tier through to the first tier.
+func (c *TieredStore) syncCounters(ctx context.Context, values map[string]int64, ret map[string]int64, err error) error {
+	if err != nil {
+		_, _ = c.l1.ForgetMany(ctx, keysOf(values)...)
+		return err
+	}
+
+	counters := make(map[string]interface{}, len(ret))
+	for key, value := range ret {
+		counters[key] = value
+	}
+
+	return c.l1.SetMany(ctx, counters, c.l1Expire)
+}
+
+// keysOf Return the keys of a map.
+func keysOf(values interface{}) []string {
+	var keys []string
+
+	switch v := values.(type) {
+	case map[string]interface{}:
+		keys = make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]int64:
+		keys = make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]time.Duration:
+		keys = make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,107 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/17 3:10 下午
+ * @Desc: tiered store test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestTieredStore_Get(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		l1    = newMemoryStore(&cache.MemoryOptions{Prefix: "cache", CleanupInterval: -1})
+		l2    = newMemoryStore(&cache.MemoryOptions{Prefix: "cache", CleanupInterval: -1})
+		store = cache.NewTieredStore(l1, l2, &cache.TieredOptions{L1Expire: time.Second})
+	)
+
+	_ = l2.Set(ctx, "name", "fuxiao", time.Minute)
+
+	if val := store.Get(ctx, "name").Val(); val != "fuxiao" {
+		t.Fatalf("tiered: unexpected value %q", val)
+	}
+
+	if val := l1.Get(ctx, "name").Val(); val != "fuxiao" {
+		t.Fatalf("tiered: expected the value to be backfilled, got %q", val)
+	}
+
+	if err := store.Forget(ctx, "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := l1.Has(ctx, "name"); ok {
+		t.Fatal("tiered: expected the value to be removed from l1")
+	}
+
+	if ok, _ := l2.Has(ctx, "name"); ok {
+		t.Fatal("tiered: expected the value to be removed from l2")
+	}
+}
+
+func TestTieredStore_GetSet(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		l1    = newMemoryStore(&cache.MemoryOptions{Prefix: "cache", CleanupInterval: -1})
+		l2    = newMemoryStore(&cache.MemoryOptions{Prefix: "cache", CleanupInterval: -1})
+		store = cache.NewTieredStore(l1, l2, nil)
+		calls int
+	)
+
+	fn := func() (interface{}, time.Duration, error) {
+		calls++
+		return "fuxiao", time.Minute, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if val := store.GetSet(ctx, "name", fn).Val(); val != "fuxiao" {
+			t.Fatalf("tiered: unexpected value %q", val)
+		}
+	}
+
+	_ = l1.Flush(ctx)
+
+	if val := store.GetSet(ctx, "name", fn).Val(); val != "fuxiao" {
+		t.Fatalf("tiered: unexpected value %q", val)
+	}
+
+	if calls != 1 {
+		t.Fatalf("tiered: expected the loader to be called once, called %d times", calls)
+	}
+}
+
+func TestTieredStore_Set(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		l1    = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		l2    = newMemoryStore(&cache.MemoryOptions{CleanupInterval: -1})
+		store = cache.NewTieredStore(l1, l2, &cache.TieredOptions{L1Expire: 20 * time.Millisecond})
+	)
+
+	_ = store.Set(ctx, "name", "fuxiao", time.Minute)
+
+	time.Sleep(30 * time.Millisecond)
+
+	if ok, _ := l1.Has(ctx, "name"); ok {
+		t.Fatal("tiered: expected the l1 item to expire earlier")
+	}
+
+	if ok, _ := l2.Has(ctx, "name"); !ok {
+		t.Fatal("tiered: expected the l2 item to exist")
+	}
+
+	if val, _ := store.Increment(ctx, "count", 2); val != 2 {
+		t.Fatalf("tiered: unexpected counter %d", val)
+	}
+
+	if val := l1.Get(ctx, "count").Val(); val != "2" {
+		t.Fatalf("tiered: expected the counter to be written through, got %q", val)
+	}
+}
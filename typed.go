@@ -0,0 +1,118 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 2:40 上午
+ * @Desc: a typed cache instance
+ */
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+type TypedCache[T any] struct {
+	cache Cache
+}
+
+// Typed Create a typed cache instance on top of a cache, the values are encoded with the configured codec.
+func Typed[T any](c Cache) *TypedCache[T] {
+	return &TypedCache[T]{cache: c}
+}
+
+// Get Retrieve an item from the cache by key, cache.Nil is returned if it's missing.
+func (c *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	return scanResult[T](c.cache.Get(ctx, key))
+}
+
+// GetMany Retrieve multiple items from the cache by key, the missing items are left out.
+func (c *TypedCache[T]) GetMany(ctx context.Context, keys []string) (map[string]T, error) {
+	rst, err := c.cache.GetMany(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]T, len(rst))
+	for key, r := range rst {
+		val, err := scanResult[T](r)
+		if err == Nil {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		ret[key] = val
+	}
+
+	return ret, nil
+}
+
+// Remember Retrieve an item from the cache by key, or store the item returned by the function for the ttl.
+// The function may return cache.Nil to cache the missing item for a while.
+func (c *TypedCache[T]) Remember(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (T, error), opt ...*GetSetOptions) (T, error) {
+	return scanResult[T](c.cache.GetSet(ctx, key, func() (interface{}, time.Duration, error) {
+		val, err := fn(ctx)
+		return typedValue(val), ttl, err
+	}, opt...))
+}
+
+// Set Store an item in the cache.
+func (c *TypedCache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return c.cache.Set(ctx, key, typedValue(value), ttl)
+}
+
+// Cache Get the underlying cache.
+func (c *TypedCache[T]) Cache() Cache {
+	return c.cache
+}
+
+// scanResult Decode the value of a result into a value of the type.
+func scanResult[T any](rst Result) (T, error) {
+	var val T
+
+	if err := rst.Err(); err != nil {
+		return val, err
+	}
+
+	// a bool is stored as "true" or "false", which conv.Scan doesn't read back.
+	if b, ok := any(&val).(*bool); ok {
+		var s string
+		if err := rst.Scan(&s); err != nil {
+			return val, err
+		}
+
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return val, err
+		}
+
+		*b = v
+		return val, nil
+	}
+
+	if err := rst.Scan(&val); err != nil {
+		return val, err
+	}
+
+	return val, nil
+}
+
+// typedValue Convert a value into the form scanResult reads back, a time is stored in RFC3339Nano.
+func typedValue[T any](value T) interface{} {
+	switch v := any(value).(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+
+		return v.Format(time.RFC3339Nano)
+	}
+
+	return value
+}
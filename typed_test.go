@@ -0,0 +1,79 @@
+/**
+ * @Author: fuxiao
+ * @Email: 576101059@qq.com
+ * @Date: 2026/10/18 3:00 上午
+ * @Desc: typed cache test
+ */
+
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dobyte/cache"
+)
+
+func TestTyped(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		c        = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache", Codec: cache.MsgpackCodec{}})
+		students = cache.Typed[codecStudent](c)
+	)
+
+	if err := students.Set(ctx, "fuxiao", codecStudent{Name: "fuxiao", Age: 30}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := students.Get(ctx, "fuxiao"); err != nil || s.Age != 30 {
+		t.Fatalf("typed: unexpected value %+v, %v", s, err)
+	}
+
+	if _, err := students.Get(ctx, "missing"); err != cache.Nil {
+		t.Fatalf("typed: expected cache.Nil, got %v", err)
+	}
+
+	rst, err := students.GetMany(ctx, []string{"fuxiao", "missing"})
+	if err != nil || len(rst) != 1 || rst["fuxiao"].Name != "fuxiao" {
+		t.Fatalf("typed: unexpected values %v, %v", rst, err)
+	}
+
+	var calls int
+	for i := 0; i < 2; i++ {
+		s, err := students.Remember(ctx, "lucy", time.Minute, func(ctx context.Context) (codecStudent, error) {
+			calls++
+			return codecStudent{Name: "lucy", Age: 18}, nil
+		})
+		if err != nil || s.Name != "lucy" {
+			t.Fatalf("typed: unexpected value %+v, %v", s, err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("typed: expected the value to be remembered, called %d times", calls)
+	}
+}
+
+func TestTyped_Scalar(t *testing.T) {
+	var (
+		ctx = context.Background()
+		c   = cache.NewCache(&cache.Options{Driver: cache.MemoryDriver, Prefix: "cache"})
+		now = time.Now().Round(0)
+	)
+
+	_ = cache.Typed[bool](c).Set(ctx, "ok", true, time.Minute)
+	if ok, err := cache.Typed[bool](c).Get(ctx, "ok"); err != nil || !ok {
+		t.Fatalf("typed: unexpected bool %v, %v", ok, err)
+	}
+
+	_ = cache.Typed[time.Time](c).Set(ctx, "now", now, time.Minute)
+	if val, err := cache.Typed[time.Time](c).Get(ctx, "now"); err != nil || !val.Equal(now) {
+		t.Fatalf("typed: unexpected time %v, %v", val, err)
+	}
+
+	_ = cache.Typed[int64](c).Set(ctx, "count", 1, time.Minute)
+	if val, _ := c.Increment(ctx, "count", 1); val != 2 {
+		t.Fatalf("typed: expected the integers to stay countable, got %d", val)
+	}
+}